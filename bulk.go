@@ -0,0 +1,164 @@
+package storm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//insertAll inserts all the structures of the slice with multi-row inserts
+//The rows are chunked by the bind variable limit of the dialect, rows with and without a preset auto increment id are inserted separately
+func (storm *Storm) insertAll(i interface{}, tx *Transaction) error {
+	vs := reflect.ValueOf(i)
+	if vs.Kind() != reflect.Ptr {
+		return errors.New("provided input is not by reference")
+	}
+
+	vs = vs.Elem()
+	if vs.Kind() != reflect.Slice {
+		return errors.New("provided input is not a slice")
+	}
+
+	t := typeIndirect(vs.Type().Elem())
+	if t.Kind() != reflect.Struct {
+		return errors.New("provided input slice has no structure type")
+	}
+
+	tbl, ok := storm.table(t)
+	if !ok {
+		return fmt.Errorf("no registered structure for `%s` found", t)
+	}
+
+	//prepare the elements like a single insert
+	elems := make([]reflect.Value, vs.Len())
+	for n := range elems {
+		v := vs.Index(n)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return fmt.Errorf("cannot insert nil element at index %d", n)
+			}
+			v = v.Elem()
+		}
+		elems[n] = v
+
+		if err := storm.touch(v, tbl, true); err != nil {
+			return err
+		}
+		if err := tbl.callbacks.invoke(v.Addr(), "OnInsert", tx); err != nil {
+			return err
+		}
+		if err := storm.generateKey(v, tbl); err != nil {
+			return err
+		}
+	}
+
+	maxRows := storm.maxInsertRows(tbl)
+	for start := 0; start < len(elems); {
+		//a chunk shares the same columns
+		presetAI := storm.hasPresetAI(elems[start], tbl)
+
+		end := start + 1
+		for end < len(elems) && end-start < maxRows && storm.hasPresetAI(elems[end], tbl) == presetAI {
+			end++
+		}
+
+		if err := storm.insertChunk(elems[start:end], tbl, tx); err != nil {
+			return err
+		}
+		start = end
+	}
+
+	for _, v := range elems {
+		if err := tbl.takeSnapshot(v); err != nil {
+			return err
+		}
+		if err := tbl.callbacks.invoke(v.Addr(), "OnPostInsert", tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//insertChunk executes a single multi-row insert and assigns the auto increment ids
+func (storm *Storm) insertChunk(vs []reflect.Value, tbl *table, tx *Transaction) error {
+	sqlQuery, bind := storm.generateInsertAllSQL(vs, tbl)
+	stmt, err := tx.DB().Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	if tbl.aiColumn == nil || storm.hasPresetAI(vs[0], tbl) {
+		return storm.presetAI(vs[0], tbl, tx, func() error {
+			_, err := stmt.Exec(bind...)
+			return err
+		})
+	}
+
+	ids, err := storm.dialect.InsertAutoIncrementRows(stmt, len(vs), bind...)
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(vs) {
+		return fmt.Errorf("expected %d inserted ids, got %d", len(vs), len(ids))
+	}
+	for n, v := range vs {
+		v.FieldByIndex(tbl.aiColumn.goIndex).SetInt(ids[n])
+	}
+	return nil
+}
+
+//maxInsertRows returns the number of rows fitting in a single insert statement of the dialect
+func (storm *Storm) maxInsertRows(tbl *table) int {
+	capabilities := storm.dialect.Capabilities()
+	rows := int(^uint(0) >> 1)
+	if capabilities.MaxBindVars > 0 {
+		rows = capabilities.MaxBindVars / len(tbl.columns)
+	}
+
+	if capabilities.MaxInsertRows > 0 && rows > capabilities.MaxInsertRows {
+		rows = capabilities.MaxInsertRows
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+//generateInsertAllSQL creates a multi-row insert, the columns are determined by the first structure
+func (storm *Storm) generateInsertAllSQL(vs []reflect.Value, tbl *table) (string, []interface{}) {
+	var (
+		columns  []*column
+		names    []string
+		rows     = make([]string, len(vs))
+		bind     = make([]interface{}, 0)
+		aiColumn string
+	)
+
+	presetAI := storm.hasPresetAI(vs[0], tbl)
+	for _, col := range tbl.columns {
+		if col != tbl.aiColumn || presetAI {
+			columns = append(columns, col)
+			names = append(names, col.columnName)
+		}
+	}
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	for n, v := range vs {
+		rows[n] = placeholders
+		for _, col := range columns {
+			bind = append(bind, col.bindValue(v))
+		}
+	}
+
+	if tbl.aiColumn != nil && !presetAI {
+		aiColumn = tbl.aiColumn.columnName
+	}
+
+	return rebind(storm.dialect, storm.dialect.InsertSQL(tbl.tableName, names, rows, aiColumn)), bind
+}
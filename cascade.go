@@ -0,0 +1,260 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//cascadedRelation is a related structure saved together with the owning structure
+type cascadedRelation struct {
+	rel     *relation
+	tbl     *table
+	cascade []string
+}
+
+//cascadedRelations resolves the provided and tagged (`db:"cascade(save)"`) relations
+//Parents are referenced by a column of the structure and saved first, children reference the structure and are saved after
+func (storm *Storm) cascadedRelations(tbl *table, names []string) (parents []*cascadedRelation, children []*cascadedRelation, err error) {
+	var cascaded []*cascadedRelation
+	find := func(rel *relation) *cascadedRelation {
+		for _, c := range cascaded {
+			if c.rel == rel {
+				return c
+			}
+		}
+		return nil
+	}
+
+	//group the nested relations
+	for _, name := range names {
+		parts := strings.SplitN(name, ".", 2)
+		rel := tbl.findRelation(parts[0])
+		if rel == nil {
+			return nil, nil, fmt.Errorf("unknown relation `%s` in table `%s`", parts[0], tbl.tableName)
+		}
+
+		c := find(rel)
+		if c == nil {
+			c = &cascadedRelation{rel: rel}
+			cascaded = append(cascaded, c)
+		}
+		if len(parts) > 1 {
+			c.cascade = append(c.cascade, parts[1])
+		}
+	}
+
+	for _, rel := range tbl.relations {
+		if hasSetting(rel.settings, "cascade", "save") && find(rel) == nil {
+			cascaded = append(cascaded, &cascadedRelation{rel: rel})
+		}
+	}
+
+	for _, c := range cascaded {
+		relTbl, ok := storm.table(typeIndirect(c.rel.goSingularType))
+		if !ok {
+			return nil, nil, fmt.Errorf("no registered structure for `%s` found", typeIndirect(c.rel.goSingularType))
+		}
+		c.tbl = relTbl
+
+		switch {
+		case c.rel.joinTable != "":
+			return nil, nil, fmt.Errorf("cannot cascade many to many relation `%s` of table `%s`", c.rel.name, tbl.tableName)
+		case c.rel.relTable != nil:
+			children = append(children, c)
+		case c.rel.relColumn != nil:
+			parents = append(parents, c)
+		default:
+			return nil, nil, fmt.Errorf("cannot cascade relation `%s` of table `%s`, no foreign key column found", c.rel.name, tbl.tableName)
+		}
+	}
+	return parents, children, nil
+}
+
+//saveParents saves the referenced structures and assigns their primary key to the foreign key columns
+func (storm *Storm) saveParents(v reflect.Value, tbl *table, tx *Transaction, parents []*cascadedRelation) error {
+	for _, c := range parents {
+		field := v.FieldByIndex(c.rel.goIndex)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+
+		key := c.rel.referencedKey(c.tbl)
+		if key == nil {
+			return fmt.Errorf("cannot cascade to table `%s` without a single primary key", c.tbl.tableName)
+		}
+
+		if err := storm.saveValue(field, c.tbl, tx, &saveOptions{cascade: c.cascade}); err != nil {
+			return err
+		}
+
+		if err := setForeignKey(v.FieldByIndex(c.rel.relColumn.goIndex), field.FieldByIndex(key.goIndex).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//saveChildren assigns the primary key of the structure to the foreign key of the children and saves them
+func (storm *Storm) saveChildren(v reflect.Value, tbl *table, tx *Transaction, children []*cascadedRelation) error {
+	for _, c := range children {
+		key := c.rel.referencedKey(tbl)
+		if key == nil {
+			return fmt.Errorf("cannot cascade from table `%s` without a single primary key", tbl.tableName)
+		}
+		keyValue := v.FieldByIndex(key.goIndex).Interface()
+
+		field := v.FieldByIndex(c.rel.goIndex)
+
+		var elems []reflect.Value
+		if field.Kind() == reflect.Slice {
+			for i := 0; i < field.Len(); i++ {
+				elems = append(elems, field.Index(i))
+			}
+		} else {
+			elems = append(elems, field)
+		}
+
+		for _, elem := range elems {
+			if elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem()
+			}
+
+			if err := setForeignKey(elem.FieldByIndex(c.rel.relColumn.goIndex), keyValue); err != nil {
+				return err
+			}
+
+			if err := storm.saveValue(elem, c.tbl, tx, &saveOptions{cascade: c.cascade}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//setForeignKey assigns the primary key value to a foreign key field, scanners (e.g. sql.NullInt64) and pointers are supported
+func setForeignKey(field reflect.Value, key interface{}) error {
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(key)
+	}
+
+	k := reflect.ValueOf(key)
+	if field.Kind() == reflect.Ptr && k.Type().ConvertibleTo(field.Type().Elem()) {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(k.Convert(field.Type().Elem()))
+		field.Set(ptr)
+		return nil
+	}
+
+	if !k.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("cannot assign key of type `%T` to the foreign key of type `%s`", key, field.Type())
+	}
+	field.Set(k.Convert(field.Type()))
+	return nil
+}
+
+//deleteRule is the `ondelete` rule of a one to many relation
+type deleteRule struct {
+	rel    *relation
+	tbl    *table
+	action string
+}
+
+//deleteRules returns the relations with a `db:"ondelete(cascade|restrict|setnull)"` rule
+func (storm *Storm) deleteRules(tbl *table) ([]*deleteRule, error) {
+	var rules []*deleteRule
+	for _, rel := range tbl.relations {
+		action, ok := rel.settings["ondelete"]
+		if !ok {
+			continue
+		}
+
+		switch action {
+		case "cascade", "restrict", "setnull":
+		default:
+			return nil, fmt.Errorf("unknown delete rule `%s` on relation `%s` of table `%s`", action, rel.name, tbl.tableName)
+		}
+
+		if rel.relTable == nil || rel.joinTable != "" {
+			return nil, fmt.Errorf("delete rule on relation `%s` of table `%s` requires a one to many relation", rel.name, tbl.tableName)
+		}
+		if rel.referencedKey(tbl) == nil {
+			return nil, fmt.Errorf("cannot apply delete rules on table `%s` without a single primary key", tbl.tableName)
+		}
+		rules = append(rules, &deleteRule{rel: rel, tbl: rel.relTable, action: action})
+	}
+	return rules, nil
+}
+
+//relatedQuery creates the query matching the related records referencing the structure
+//When the record is removed, soft deleted related records still reference it and are matched as well
+func (rule *deleteRule) relatedQuery(v reflect.Value, tbl *table, tx *Transaction, removed bool) *Query {
+	key := rule.rel.referencedKey(tbl)
+	query := tx.Where(fmt.Sprintf("%s = ?", rule.rel.relColumn.columnName), v.FieldByIndex(key.goIndex).Interface())
+	if removed {
+		query.Unscoped()
+	}
+	return query
+}
+
+//restrictDelete rejects the delete when a restricted relation still has related records
+func (storm *Storm) restrictDelete(v reflect.Value, tbl *table, tx *Transaction, rules []*deleteRule, hard bool) error {
+	removed := hard || tbl.deletedColumn == nil
+	for _, rule := range rules {
+		if rule.action != "restrict" {
+			continue
+		}
+
+		cnt, err := rule.relatedQuery(v, tbl, tx, removed).Count(reflect.New(rule.tbl.goType).Interface())
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return fmt.Errorf("cannot delete record of `%s`, relation `%s` still has %d related records", tbl.tableName, rule.rel.name, cnt)
+		}
+	}
+	return nil
+}
+
+//cascadeDelete deletes the related records one by one, with their own delete rules and callbacks, or resets their foreign key
+//A soft deleted record can be restored, so the foreign keys referencing it are kept
+func (storm *Storm) cascadeDelete(v reflect.Value, tbl *table, tx *Transaction, rules []*deleteRule, hard bool) error {
+	removed := hard || tbl.deletedColumn == nil
+	for _, rule := range rules {
+		if rule.action == "restrict" {
+			continue
+		}
+
+		if rule.action == "setnull" {
+			if !removed {
+				continue
+			}
+			if _, err := rule.relatedQuery(v, tbl, tx, removed).Update(reflect.New(rule.tbl.goType).Interface(), Set{rule.rel.relColumn.columnName: nil}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		related := reflect.New(reflect.SliceOf(reflect.PtrTo(rule.tbl.goType)))
+		err := rule.relatedQuery(v, tbl, tx, removed).Find(related.Interface())
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for i := 0; i < related.Elem().Len(); i++ {
+			if err = storm.deleteValue(related.Elem().Index(i).Elem(), rule.tbl, tx, hard); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -9,3 +9,14 @@ func typeIndirect(t reflect.Type) reflect.Type {
 	}
 	return t
 }
+
+//isEmptyValue checks if the field has no value yet
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
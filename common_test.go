@@ -87,6 +87,285 @@ type testAllTypeStructure struct {
 	PtrBool        *bool
 }
 
+type testJsonSettings struct {
+	Theme  string
+	Notify bool
+}
+
+type testJsonStructure struct {
+	Id          int
+	Settings    testJsonSettings       `db:"json"`
+	SettingsPtr *testJsonSettings      `db:"json"`
+	Tags        []string               `db:"json"`
+	Meta        map[string]interface{} `db:"name(metadata),json"`
+}
+
+type testEmbedAddress struct {
+	Street      string
+	City        string
+	CountryCode string `db:"name(country)"`
+}
+
+type testEmbedStructure struct {
+	Id       int
+	Name     string
+	Billing  testEmbedAddress `db:"embed,prefix(billing_)"`
+	Shipping testEmbedAddress `db:"embed,prefix(shipping_)"`
+}
+
+type testCompositeKey struct {
+	CustomerId int `db:"pk"`
+	TagId      int `db:"pk"`
+	Position   int
+}
+
+type testNaturalKey struct {
+	Code string `db:"pk"`
+	Hash []byte `db:"pk"`
+	Name string
+}
+
+type testNaturalPopulation struct {
+	CountryCode string `db:"pk,size(2)"`
+	Year        int    `db:"pk"`
+	Population  int
+}
+
+type testNaturalCountry struct {
+	Code int `db:"pk"`
+	Name string
+}
+
+type testNaturalAddress struct {
+	Id        int
+	Line      string
+	Country   *testNaturalCountry
+	CountryId int
+}
+
+type testAccount struct {
+	Id        int
+	Code      string
+	Name      string
+	Transfers []testTransfer `db:"fk(source_code),references(code)"`
+}
+
+type testTransfer struct {
+	Id         int
+	Amount     int
+	SourceCode string
+	TargetCode string
+	Source     *testAccount `db:"fk(source_code),references(code)"`
+	Target     *testAccount `db:"fk(target_code),references(code)"`
+}
+
+type testInvalidForeignKey struct {
+	Id      int
+	Account *testAccount `db:"fk(account_code)"`
+}
+
+type testInvalidReference struct {
+	Id        int
+	AccountId string
+	Account   *testAccount `db:"references(number)"`
+}
+
+type testCategory struct {
+	Id       int
+	Name     string
+	ParentId sql.NullInt64
+	Children []testCategory `db:"fk(parent_id)"`
+}
+
+type testUuidKey struct {
+	Id   string `db:"pk,gen(uuid)"`
+	Name string
+}
+
+type testBinaryKey struct {
+	Id   []byte `db:"pk,gen(ulid)"`
+	Name string
+}
+
+type testUpsertStructure struct {
+	Id    int
+	Email string
+	Name  string
+	Score int
+
+	onInsertInvoked bool
+	onUpdateInvoked bool
+}
+
+func (t *testUpsertStructure) OnInsert() { t.onInsertInvoked = true }
+func (t *testUpsertStructure) OnUpdate() { t.onUpdateInvoked = true }
+
+type testTrackedStructure struct {
+	Snapshot
+	Id    int
+	Name  string
+	Email *string
+	Tags  []string `db:"json"`
+}
+
+type testVersionedStructure struct {
+	Id      int
+	Name    string
+	Version int `db:"version"`
+}
+
+type testTrackedVersionedStructure struct {
+	Snapshot
+	Id      int
+	Name    string
+	Version int `db:"version"`
+}
+
+type testTimestampStructure struct {
+	Id        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type testTaggedTimestampStructure struct {
+	Id      int
+	Added   time.Time `db:"created"`
+	Changed time.Time `db:"updated"`
+}
+
+type testSoftDeleteStructure struct {
+	Id        int
+	Name      string
+	DeletedAt *time.Time `db:"softdelete"`
+	Notes     []testSoftDeleteNote
+}
+
+type testSoftDeleteNote struct {
+	Id                        int
+	TestSoftDeleteStructureId int
+	Text                      string
+	DeletedAt                 *time.Time `db:"softdelete"`
+}
+
+type testSoftDeleteParent struct {
+	Id        int
+	DeletedAt *time.Time            `db:"softdelete"`
+	Children  []testSoftDeleteChild `db:"ondelete(restrict)"`
+	Comments  []testSoftDeleteLink  `db:"ondelete(setnull)"`
+}
+
+type testSoftDeleteChild struct {
+	Id                     int
+	TestSoftDeleteParentId int
+	DeletedAt              *time.Time `db:"softdelete"`
+}
+
+type testSoftDeleteLink struct {
+	Id                     int
+	TestSoftDeleteParentId sql.NullInt64
+}
+
+type testSoftDeleteOwned struct {
+	Id      int
+	Name    string
+	Owner   testSoftDeleteStructure
+	OwnerId int
+}
+
+type testCascadeCustomer struct {
+	Id          int
+	Name        string
+	Telephone   *testCascadeTelephone
+	TelephoneId int
+	Addresses   []testCascadeAddress
+}
+
+type testCascadeTelephone struct {
+	Id     int
+	Number string
+}
+
+type testCascadeAddress struct {
+	Id                    int
+	TestCascadeCustomerId int
+	Line                  string
+	Country               *testCascadeCountry `db:"cascade(save)"`
+	CountryId             sql.NullInt64
+}
+
+type testCascadeCountry struct {
+	Id   int
+	Name string
+}
+
+type testDeleteCustomer struct {
+	Id        int
+	Name      string
+	Addresses []testDeleteAddress `db:"ondelete(cascade)"`
+	Orders    []testDeleteOrder   `db:"ondelete(restrict)"`
+	Notes     []testDeleteNote    `db:"ondelete(setnull)"`
+}
+
+type testDeleteAddress struct {
+	Id                   int
+	TestDeleteCustomerId int
+	Line                 string
+	Lines                []testDeleteLine `db:"ondelete(cascade)"`
+}
+
+type testDeleteLine struct {
+	Id                  int
+	TestDeleteAddressId int
+	Text                string
+}
+
+//testDeleteCallbacks records the delete callbacks of the cascaded lines
+var testDeleteCallbacks []string
+
+func (t *testDeleteLine) OnDelete() {
+	testDeleteCallbacks = append(testDeleteCallbacks, "OnDelete "+t.Text)
+}
+
+func (t *testDeleteLine) OnPostDelete() {
+	testDeleteCallbacks = append(testDeleteCallbacks, "OnPostDelete "+t.Text)
+}
+
+type testDeleteOrder struct {
+	Id                   int
+	TestDeleteCustomerId int
+}
+
+type testDeleteNote struct {
+	Id                   int
+	TestDeleteCustomerId sql.NullInt64
+	Text                 string
+}
+
+type testTaggedCustomer struct {
+	Id   int
+	Name string
+	Tags []testTag `db:"many2many(test_customer_tag)"`
+}
+
+type testTag struct {
+	Id        int
+	Name      string
+	Customers []*testTaggedCustomer `db:"many2many(test_customer_tag)"`
+}
+
+type testFriend struct {
+	Id        int
+	Name      string
+	Friends   []testFriend `db:"many2many(test_friendship),joinkey(friend_id),joinrelkey(befriended_id)"`
+	FriendsOf []testFriend `db:"many2many(test_friendship),joinkey(befriended_id),joinrelkey(friend_id)"`
+}
+
+type testInvalidFriend struct {
+	Id      int
+	Friends []testInvalidFriend `db:"many2many(test_invalid_friendship)"`
+}
+
 type Person struct {
 	Id                int
 	Name              string
@@ -106,7 +106,7 @@ func (s *dependendSuite) TestDependentColumns(c *C) {
 		"`person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id`, "+
 		"`person_address`.`id`, `person_address`.`line1`, `person_address`.`line2`, `person_address`.`country_id` "+
 		"FROM `person` AS `person` "+
-		"JOIN address AS person_address ON person.address_id = person_address.id")
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id`")
 
 	c.Assert(remainingDepends, HasLen, 2)
 	c.Assert(remainingDepends, DeepEquals, []depends{
@@ -128,8 +128,8 @@ func (s *dependendSuite) TestDependentColumns_Where(c *C) {
 		"`person_optional_address`.`id`, `person_optional_address`.`line1`, `person_optional_address`.`line2`, `person_optional_address`.`country_id`, "+
 		"`person_address`.`id`, `person_address`.`line1`, `person_address`.`line2`, `person_address`.`country_id` "+
 		"FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN address AS person_address ON person.address_id = person_address.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` "+
 		"WHERE `person_optional_address`.`id` = ?")
 
 	c.Assert(remainingDepends, HasLen, 1)
@@ -150,8 +150,8 @@ func (s *dependendSuite) TestDependentColumns_JoinDeep(c *C) {
 		"`person_address`.`id`, `person_address`.`line1`, `person_address`.`line2`, `person_address`.`country_id`, "+
 		"`person_address_country`.`id`, `person_address_country`.`name` "+
 		"FROM `person` AS `person` "+
-		"JOIN address AS person_address ON person.address_id = person_address.id "+
-		"JOIN country AS person_address_country ON person_address.country_id = person_address_country.id")
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` "+
+		"JOIN `country` AS `person_address_country` ON `person_address`.`country_id` = `person_address_country`.`id`")
 
 	c.Assert(remainingDepends, HasLen, 2)
 	c.Assert(remainingDepends, DeepEquals, []depends{
@@ -175,10 +175,10 @@ func (s *dependendSuite) TestDependentColumns_WhereDeep(c *C) {
 		"`person_address`.`id`, `person_address`.`line1`, `person_address`.`line2`, `person_address`.`country_id`, "+
 		"`person_address_country`.`id`, `person_address_country`.`name` "+
 		"FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN country AS person_optional_address_country ON person_optional_address.country_id = person_optional_address_country.id "+
-		"JOIN address AS person_address ON person.address_id = person_address.id "+
-		"JOIN country AS person_address_country ON person_address.country_id = person_address_country.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `country` AS `person_optional_address_country` ON `person_optional_address`.`country_id` = `person_optional_address_country`.`id` "+
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` "+
+		"JOIN `country` AS `person_address_country` ON `person_address`.`country_id` = `person_address_country`.`id` "+
 		"WHERE `person_optional_address_country`.`name` = ?")
 
 	c.Assert(remainingDepends, HasLen, 1)
@@ -201,9 +201,9 @@ func (s *dependendSuite) TestDependentColumns_LevelDeepOptional(c *C) {
 		"`parent_person_person_address`.`id`, `parent_person_person_address`.`line1`, `parent_person_person_address`.`line2`, `parent_person_person_address`.`country_id`, "+
 		"`parent_person_person_address_country`.`id`, `parent_person_person_address_country`.`name` "+
 		"FROM `parent_person` AS `parent_person` "+
-		"JOIN person AS parent_person_person ON parent_person.person_id = parent_person_person.id "+
-		"JOIN address AS parent_person_person_address ON parent_person_person.address_id = parent_person_person_address.id "+
-		"JOIN country AS parent_person_person_address_country ON parent_person_person_address.country_id = parent_person_person_address_country.id")
+		"JOIN `person` AS `parent_person_person` ON `parent_person`.`person_id` = `parent_person_person`.`id` "+
+		"JOIN `address` AS `parent_person_person_address` ON `parent_person_person`.`address_id` = `parent_person_person_address`.`id` "+
+		"JOIN `country` AS `parent_person_person_address_country` ON `parent_person_person_address`.`country_id` = `parent_person_person_address_country`.`id`")
 
 	c.Assert(remainingDepends, HasLen, 1)
 	c.Assert(remainingDepends, DeepEquals, []depends{
@@ -1,23 +1,81 @@
 package dialect
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Capabilities describes the optional features a dialect supports
+type Capabilities struct {
+	Returning  bool //generated values can be returned by the insert statement itself (RETURNING / OUTPUT)
+	Upsert     bool //insert or update on a conflicting unique key
+	Savepoints bool //nested rollback points within a transaction
+	ForUpdate  bool //row locking with SELECT ... FOR UPDATE
+	DeleteJoin bool //joined tables in a delete statement with DELETE alias FROM ... JOIN
+	Recursive  bool //recursive common table expressions with WITH RECURSIVE
+
+	MaxBindVars   int //maximum number of bind variables in a single statement, 0 is unlimited
+	MaxInsertRows int //maximum number of rows in a multi-row insert, 0 is unlimited
+}
 
 type Dialect interface {
+	Name() string
 	InsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, error)
-	SqlType(column interface{}, size int) string
+	InsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error)
+	InsertSQL(table string, columns []string, rows []string, aiColumn string) string
+	PresetAutoIncrementSQL(table string, aiColumn string) (before string, after string)
+	UpsertSQL(insertSQL string, conflict []string, update []string) string
+	RecursiveSQL(name string, columns []string, query string, selectSQL string) string
+	SqlType(column interface{}, size int) (string, error)
 	SqlPrimaryKey(column interface{}, size int) string
 	Quote(string) string
+	BindVar(i int) string
+	Bool(b bool) string
+	Paging(limit int, offset int, ordered bool) string
+	Capabilities() Capabilities
 }
 
-func New(driver string) Dialect {
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]func() Dialect)
+)
 
-	switch driver {
-	case "mysql":
-		return &mysql{}
-	case "sqlite3":
-		return &sqlite3{}
+func init() {
+	Register("mysql", func() Dialect { return &mysql{} })
+	Register("sqlite3", func() Dialect { return &sqlite3{} })
+	Register("sqlite", func() Dialect { return &sqlite3{} }) //modernc.org/sqlite
+	Register("postgres", func() Dialect { return &postgres{} })
+	Register("pgx", func() Dialect { return &postgres{} })
+	Register("mssql", func() Dialect { return &mssql{} })
+	Register("sqlserver", func() Dialect { return &mssql{} })
+}
+
+//Register makes a dialect available for the provided driver name.
+//If Register is called twice with the same name or if factory is nil, it panics.
+func Register(driver string, factory func() Dialect) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("dialect: Register factory is nil")
+	}
+	if _, dup := factories[driver]; dup {
+		panic("dialect: Register called twice for driver " + driver)
 	}
-	return nil
+	factories[driver] = factory
+}
+
+//New returns a new dialect for the driver name, nil is returned when there is no dialect registered for the driver
+func New(driver string) Dialect {
+	factoriesMu.RLock()
+	factory, ok := factories[driver]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return factory()
 }
 
 //-- Helper functions ---------------
@@ -29,3 +87,98 @@ func defaultInsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, err
 
 	return res.LastInsertId()
 }
+
+//defaultInsertAutoIncrementRows the rows of a single insert get consecutive ids, the last insert id is the id of the first row
+func defaultInsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	res, err := stmt.Exec(bind...)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, rows)
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+	return ids, nil
+}
+
+//returningInsertAutoIncrementRows scans the ids returned by the insert statement
+//The returned order is not guaranteed, the ids are sorted as they are generated in the order of the rows
+func returningInsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	res, err := stmt.Query(bind...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	ids := make([]int64, 0, rows)
+	for res.Next() {
+		var id int64
+		if err = res.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = res.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func defaultPaging(limit int, offset int) string {
+	sql := ""
+	if limit > 0 {
+		sql = fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	if offset > 0 {
+		sql = sql + fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return sql
+}
+
+//defaultUpsertSQL resolves the conflict with ON CONFLICT, the updated columns get the values of the rejected insert
+func defaultUpsertSQL(d Dialect, insertSQL string, conflict []string, update []string) string {
+	quoted := make([]string, len(conflict))
+	for i, col := range conflict {
+		quoted[i] = d.Quote(col)
+	}
+
+	if len(update) == 0 {
+		return insertSQL + " ON CONFLICT (" + strings.Join(quoted, ", ") + ") DO NOTHING"
+	}
+
+	set := make([]string, len(update))
+	for i, col := range update {
+		set[i] = d.Quote(col) + " = excluded." + d.Quote(col)
+	}
+	return insertSQL + " ON CONFLICT (" + strings.Join(quoted, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+//defaultRecursiveSQL creates the select preceded by the recursive common table expression
+func defaultRecursiveSQL(d Dialect, name string, columns []string, query string, selectSQL string) string {
+	return "WITH RECURSIVE " + commonTableExpression(d, name, columns, query) + " " + selectSQL
+}
+
+func commonTableExpression(d Dialect, name string, columns []string, query string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.Quote(col)
+	}
+	return d.Quote(name) + " (" + strings.Join(quoted, ", ") + ") AS (" + query + ")"
+}
+
+func defaultInsertSQL(d Dialect, table string, columns []string, rows []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.Quote(col)
+	}
+	return "INSERT INTO " + d.Quote(table) + " (" + strings.Join(quoted, ", ") + ") VALUES " + strings.Join(rows, ", ")
+}
@@ -0,0 +1,174 @@
+package dialect
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mbict/null"
+)
+
+type mssql struct {
+}
+
+func (*mssql) Name() string {
+	return "mssql"
+}
+
+func (*mssql) InsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, error) {
+	var id int64
+	err := stmt.QueryRow(bind...).Scan(&id)
+	return id, err
+}
+
+//InsertAutoIncrementRows the order of the OUTPUT rows is not guaranteed, the ids are sorted as they are assigned in the order of the rows
+func (*mssql) InsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	return returningInsertAutoIncrementRows(stmt, rows, bind...)
+}
+
+//InsertSQL the generated key is retreived with the OUTPUT clause
+//Multiple rows are inserted with a select ordered by the position of the row, only then the identity values are assigned in the order of the rows
+func (d *mssql) InsertSQL(table string, columns []string, rows []string, aiColumn string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.Quote(col)
+	}
+
+	sql := "INSERT INTO " + d.Quote(table) + " (" + strings.Join(quoted, ", ") + ")"
+	if aiColumn == "" {
+		return sql + " VALUES " + strings.Join(rows, ", ")
+	}
+
+	sql = sql + " OUTPUT INSERTED." + d.Quote(aiColumn)
+	if len(rows) == 1 || len(columns) == 0 {
+		return sql + " VALUES " + strings.Join(rows, ", ")
+	}
+
+	ordered := make([]string, len(rows))
+	for i, row := range rows {
+		ordered[i] = strings.TrimSuffix(row, ")") + fmt.Sprintf(", %d)", i)
+	}
+	return fmt.Sprintf("%s SELECT %s FROM (VALUES %s) AS %s (%s, %s) ORDER BY %s", sql, strings.Join(quoted, ", "), strings.Join(ordered, ", "),
+		d.Quote("storm_rows"), strings.Join(quoted, ", "), d.Quote("storm_ordinal"), d.Quote("storm_ordinal"))
+}
+
+//PresetAutoIncrementSQL mssql only accepts explicit values for an identity column with IDENTITY_INSERT enabled on the session
+func (d *mssql) PresetAutoIncrementSQL(table string, aiColumn string) (string, string) {
+	return "SET IDENTITY_INSERT " + d.Quote(table) + " ON", "SET IDENTITY_INSERT " + d.Quote(table) + " OFF"
+}
+
+//UpsertSQL mssql has no upsert syntax besides MERGE, which is not supported
+func (d *mssql) UpsertSQL(insertSQL string, conflict []string, update []string) string {
+	return ""
+}
+
+//RecursiveSQL mssql common table expressions are recursive without the RECURSIVE keyword
+//The default limit of 100 recursions is lifted, deeper trees are valid
+func (d *mssql) RecursiveSQL(name string, columns []string, query string, selectSQL string) string {
+	return "WITH " + commonTableExpression(d, name, columns, query) + " " + selectSQL + " OPTION (MAXRECURSION 0)"
+}
+
+func (d *mssql) SqlType(column interface{}, size int) (string, error) {
+	return resolveType(d.Name(), column, size, d.sqlType)
+}
+
+func (*mssql) sqlType(column interface{}, size int) string {
+	switch column.(type) {
+	case time.Time, *time.Time:
+		return "DATETIME2"
+	case bool, sql.NullBool, null.Bool, *bool:
+		return "BIT"
+	case int8, int16, uint8, *int8, *int16, *uint8:
+		return "SMALLINT"
+	case int, int32, uint16, *int, *int32, *uint16:
+		return "INT"
+	case int64, uint, uint32, uint64, sql.NullInt64, null.Int, *int64, *uint, *uint32, *uint64:
+		return "BIGINT"
+	case float32, *float32:
+		return "REAL"
+	case float64, sql.NullFloat64, null.Float, *float64:
+		return "FLOAT"
+	case json.RawMessage:
+		return "NVARCHAR(MAX)"
+	case []byte:
+		if size > 0 && size <= 8000 {
+			return fmt.Sprintf("VARBINARY(%d)", size)
+		} else {
+			return "VARBINARY(MAX)"
+		}
+	case string, sql.NullString, null.String, *string:
+		if size > 0 && size <= 4000 {
+			return fmt.Sprintf("NVARCHAR(%d)", size)
+		} else {
+			return "NVARCHAR(MAX)"
+		}
+	default:
+		return ""
+	}
+}
+
+func (*mssql) SqlPrimaryKey(column interface{}, size int) string {
+	switch column.(type) {
+	case int, int8, int16, int32, uint, uint8, uint16, uint32, int64, uint64:
+		return "IDENTITY(1,1) PRIMARY KEY"
+	default:
+		panic("Invalid primary key type")
+	}
+}
+
+func (*mssql) Quote(key string) string {
+	return "[" + strings.Replace(key, "]", "]]", -1) + "]"
+}
+
+//BindVar mssql uses named ordinal bind vars (@p1, @p2, ...)
+func (*mssql) BindVar(i int) string {
+	return fmt.Sprintf("@p%d", i)
+}
+
+//Bool mssql has no boolean literals, bit values are used instead
+func (*mssql) Bool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+//Paging mssql has no LIMIT, the OFFSET FETCH clause is used and requires a ORDER BY
+func (*mssql) Paging(limit int, offset int, ordered bool) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+
+	sql := ""
+	if !ordered {
+		sql = " ORDER BY (SELECT NULL)"
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+	sql = sql + fmt.Sprintf(" OFFSET %d ROWS", offset)
+
+	if limit > 0 {
+		sql = sql + fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return sql
+}
+
+//Capabilities upserts (MERGE) are not implemented and rows are locked with table hints instead of FOR UPDATE
+//A insert statement takes at most 1000 rows, a statement less than 2100 parameters
+func (*mssql) Capabilities() Capabilities {
+	return Capabilities{
+		Returning:  true,
+		Upsert:     false,
+		Savepoints: true,
+		ForUpdate:  false,
+		DeleteJoin: true,
+		Recursive:  true,
+
+		MaxBindVars:   2099,
+		MaxInsertRows: 1000,
+	}
+}
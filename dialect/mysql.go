@@ -2,7 +2,9 @@ package dialect
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mbict/null"
@@ -11,12 +13,52 @@ import (
 type mysql struct {
 }
 
+func (*mysql) Name() string {
+	return "mysql"
+}
+
 func (*mysql) InsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, error) {
 	return defaultInsertAutoIncrement(stmt, bind...)
 }
 
-func (*mysql) SqlType(column interface{}, size int) string {
+//InsertAutoIncrementRows the last insert id of mysql is the id of the first inserted row
+//The ids of the next rows are consecutive, which requires the default auto_increment_increment of 1
+func (*mysql) InsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	return defaultInsertAutoIncrementRows(stmt, rows, bind...)
+}
+
+func (d *mysql) InsertSQL(table string, columns []string, rows []string, aiColumn string) string {
+	return defaultInsertSQL(d, table, columns, rows)
+}
+
+func (*mysql) PresetAutoIncrementSQL(table string, aiColumn string) (string, string) {
+	return "", ""
+}
+
+//UpsertSQL mysql resolves conflicts on any unique key, the conflict columns are not used
+func (d *mysql) UpsertSQL(insertSQL string, conflict []string, update []string) string {
+	var set []string
+	for _, col := range update {
+		set = append(set, d.Quote(col)+" = VALUES("+d.Quote(col)+")")
+	}
+
+	//nothing to update, assigning a column to itself leaves the record untouched
+	if len(set) == 0 && len(conflict) > 0 {
+		set = append(set, d.Quote(conflict[0])+" = "+d.Quote(conflict[0]))
+	}
+	return insertSQL + " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+//RecursiveSQL mysql supports recursive common table expressions since 8.0
+func (d *mysql) RecursiveSQL(name string, columns []string, query string, selectSQL string) string {
+	return defaultRecursiveSQL(d, name, columns, query, selectSQL)
+}
+
+func (d *mysql) SqlType(column interface{}, size int) (string, error) {
+	return resolveType(d.Name(), column, size, d.sqlType)
+}
 
+func (*mysql) sqlType(column interface{}, size int) string {
 	switch column.(type) {
 	case time.Time, *time.Time:
 		return "DATETIME"
@@ -28,6 +70,8 @@ func (*mysql) SqlType(column interface{}, size int) string {
 		return "BIGINT"
 	case float32, float64, sql.NullFloat64, null.Float, *float32, *float64:
 		return "DOUBLE"
+	case json.RawMessage:
+		return "JSON"
 	case []byte:
 		if size > 0 && size < 65532 {
 			return fmt.Sprintf("VARBINARY(%d)", size)
@@ -41,7 +85,7 @@ func (*mysql) SqlType(column interface{}, size int) string {
 			return "LONGTEXT"
 		}
 	default:
-		panic(fmt.Sprintf("Invalid sql type for mysql (%#v / %T)", column))
+		return ""
 	}
 }
 
@@ -57,3 +101,36 @@ func (*mysql) SqlPrimaryKey(column interface{}, size int) string {
 func (*mysql) Quote(key string) string {
 	return fmt.Sprintf("`%s`", key)
 }
+
+func (*mysql) BindVar(i int) string {
+	return "?"
+}
+
+func (*mysql) Bool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+//Paging mysql requires a LIMIT when a OFFSET is used, the largest possible limit is used to fetch all remaining rows
+func (*mysql) Paging(limit int, offset int, ordered bool) string {
+	if offset > 0 && limit <= 0 {
+		return fmt.Sprintf(" LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return defaultPaging(limit, offset)
+}
+
+func (*mysql) Capabilities() Capabilities {
+	return Capabilities{
+		Returning:  false,
+		Upsert:     true,
+		Savepoints: true,
+		ForUpdate:  true,
+		DeleteJoin: true,
+		Recursive:  false, //mysql before 8.0 has no common table expressions, trees are loaded with a query per level
+
+		MaxBindVars:   65535,
+		MaxInsertRows: 0,
+	}
+}
@@ -0,0 +1,129 @@
+package dialect
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mbict/null"
+)
+
+type postgres struct {
+}
+
+func (*postgres) Name() string {
+	return "postgres"
+}
+
+func (*postgres) InsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, error) {
+	var id int64
+	err := stmt.QueryRow(bind...).Scan(&id)
+	return id, err
+}
+
+//InsertAutoIncrementRows the sequence values are taken in the order of the rows, the RETURNING rows are sorted by id
+func (*postgres) InsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	return returningInsertAutoIncrementRows(stmt, rows, bind...)
+}
+
+//InsertSQL postgres has no last insert id, the generated key is retreived with the RETURNING clause
+func (d *postgres) InsertSQL(table string, columns []string, rows []string, aiColumn string) string {
+	sql := defaultInsertSQL(d, table, columns, rows)
+	if aiColumn != "" {
+		sql = sql + " RETURNING " + d.Quote(aiColumn)
+	}
+	return sql
+}
+
+//PresetAutoIncrementSQL postgres sequences are not advanced by explicit values, the sequence is moved to the highest id afterwards
+func (d *postgres) PresetAutoIncrementSQL(table string, aiColumn string) (string, string) {
+	return "", fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), MAX(%s)) FROM %s", d.Quote(table), aiColumn, d.Quote(aiColumn), d.Quote(table))
+}
+
+func (d *postgres) UpsertSQL(insertSQL string, conflict []string, update []string) string {
+	return defaultUpsertSQL(d, insertSQL, conflict, update)
+}
+
+func (d *postgres) RecursiveSQL(name string, columns []string, query string, selectSQL string) string {
+	return defaultRecursiveSQL(d, name, columns, query, selectSQL)
+}
+
+func (d *postgres) SqlType(column interface{}, size int) (string, error) {
+	return resolveType(d.Name(), column, size, d.sqlType)
+}
+
+func (*postgres) sqlType(column interface{}, size int) string {
+	switch column.(type) {
+	case time.Time, *time.Time:
+		return "TIMESTAMP WITH TIME ZONE"
+	case bool, sql.NullBool, null.Bool, *bool:
+		return "BOOLEAN"
+	case int8, int16, uint8, *int8, *int16, *uint8:
+		return "SMALLINT"
+	case int, int32, uint16, *int, *int32, *uint16:
+		return "INTEGER"
+	case int64, uint, uint32, uint64, sql.NullInt64, null.Int, *int64, *uint, *uint32, *uint64:
+		return "BIGINT"
+	case float32, *float32:
+		return "REAL"
+	case float64, sql.NullFloat64, null.Float, *float64:
+		return "DOUBLE PRECISION"
+	case json.RawMessage:
+		return "JSONB"
+	case []byte:
+		return "BYTEA"
+	case string, sql.NullString, null.String, *string:
+		if size > 0 && size < 65532 {
+			return fmt.Sprintf("VARCHAR(%d)", size)
+		} else {
+			return "TEXT"
+		}
+	default:
+		return ""
+	}
+}
+
+func (*postgres) SqlPrimaryKey(column interface{}, size int) string {
+	switch column.(type) {
+	case int, int8, int16, int32, uint, uint8, uint16, uint32, int64, uint64:
+		return "GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY"
+	default:
+		panic("Invalid primary key type")
+	}
+}
+
+func (*postgres) Quote(key string) string {
+	return `"` + strings.Replace(key, `"`, `""`, -1) + `"`
+}
+
+//BindVar postgres uses numbered bind vars ($1, $2, ...)
+func (*postgres) BindVar(i int) string {
+	return fmt.Sprintf("$%d", i)
+}
+
+func (*postgres) Bool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (*postgres) Paging(limit int, offset int, ordered bool) string {
+	return defaultPaging(limit, offset)
+}
+
+func (*postgres) Capabilities() Capabilities {
+	return Capabilities{
+		Returning:  true,
+		Upsert:     true,
+		Savepoints: true,
+		ForUpdate:  true,
+		DeleteJoin: false,
+		Recursive:  true,
+
+		MaxBindVars:   65535,
+		MaxInsertRows: 0,
+	}
+}
@@ -2,39 +2,87 @@ package dialect
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/mbict/null"
 )
 
 type sqlite3 struct {
 }
 
+func (*sqlite3) Name() string {
+	return "sqlite3"
+}
+
 func (*sqlite3) InsertAutoIncrement(stmt *sql.Stmt, bind ...interface{}) (int64, error) {
 	return defaultInsertAutoIncrement(stmt, bind...)
 }
 
-func (*sqlite3) SqlType(column interface{}, size int) string {
-	switch v := column.(type) {
+//InsertAutoIncrementRows the last insert id of sqlite is the id of the last inserted row
+func (*sqlite3) InsertAutoIncrementRows(stmt *sql.Stmt, rows int, bind ...interface{}) ([]int64, error) {
+	res, err := stmt.Exec(bind...)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, rows)
+	for i := range ids {
+		ids[i] = last - int64(rows-1-i)
+	}
+	return ids, nil
+}
+
+func (d *sqlite3) InsertSQL(table string, columns []string, rows []string, aiColumn string) string {
+	return defaultInsertSQL(d, table, columns, rows)
+}
+
+func (*sqlite3) PresetAutoIncrementSQL(table string, aiColumn string) (string, string) {
+	return "", ""
+}
+
+func (d *sqlite3) UpsertSQL(insertSQL string, conflict []string, update []string) string {
+	return defaultUpsertSQL(d, insertSQL, conflict, update)
+}
+
+func (d *sqlite3) RecursiveSQL(name string, columns []string, query string, selectSQL string) string {
+	return defaultRecursiveSQL(d, name, columns, query, selectSQL)
+}
+
+func (d *sqlite3) SqlType(column interface{}, size int) (string, error) {
+	return resolveType(d.Name(), column, size, d.sqlType)
+}
+
+func (*sqlite3) sqlType(column interface{}, size int) string {
+	switch column.(type) {
 	case time.Time, *time.Time:
 		return "DATETIME"
-	case bool, sql.NullBool, *bool:
+	case bool, sql.NullBool, null.Bool, *bool:
 		return "BOOL"
 	case int, int8, int16, int32, uint, uint8, uint16, uint32, *int, *int8, *int16, *int32, *uint, *uint8, *uint16, *uint32:
 		return "INTEGER"
-	case int64, uint64, sql.NullInt64, *int64, *uint64:
+	case int64, uint64, sql.NullInt64, null.Int, *int64, *uint64:
 		return "BIGINT"
-	case float32, float64, sql.NullFloat64, *float32, *float64:
+	case float32, float64, sql.NullFloat64, null.Float, *float32, *float64:
 		return "REAL"
+	case json.RawMessage:
+		return "TEXT"
 	case []byte:
 		return "BLOB"
-	case string, sql.NullString, *string:
+	case string, sql.NullString, null.String, *string:
 		if size > 0 && size < 65532 {
 			return fmt.Sprintf("VARCHAR(%d)", size)
 		} else {
 			return "TEXT"
 		}
 	default:
-		panic(fmt.Sprintf("Invalid sql type for sqlite3 (%v, %s)", v, column))
+		return ""
 	}
 }
 
@@ -51,3 +99,39 @@ func (*sqlite3) SqlPrimaryKey(column interface{}, size int) string {
 func (*sqlite3) Quote(key string) string {
 	return fmt.Sprintf("`%s`", key)
 }
+
+func (*sqlite3) BindVar(i int) string {
+	return "?"
+}
+
+//Bool sqlite stores booleans as integers
+func (*sqlite3) Bool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+//Paging sqlite requires a LIMIT when a OFFSET is used, a negative limit means no limit
+func (*sqlite3) Paging(limit int, offset int, ordered bool) string {
+	if offset > 0 && limit <= 0 {
+		return fmt.Sprintf(" LIMIT -1 OFFSET %d", offset)
+	}
+	return defaultPaging(limit, offset)
+}
+
+//Capabilities generated ids are read from the last insert id, rows are locked by the database wide write lock
+//Bind variables are limited to 999 before sqlite 3.32
+func (*sqlite3) Capabilities() Capabilities {
+	return Capabilities{
+		Returning:  false,
+		Upsert:     true,
+		Savepoints: true,
+		ForUpdate:  false,
+		DeleteJoin: false,
+		Recursive:  true,
+
+		MaxBindVars:   999,
+		MaxInsertRows: 0,
+	}
+}
@@ -0,0 +1,111 @@
+package dialect
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type typeMapping struct {
+	goType  reflect.Type
+	sqlType string
+}
+
+var (
+	typesMu sync.RWMutex
+	types   = make(map[string][]typeMapping)
+)
+
+//RegisterType maps the go type of value to a sql column type for the dialect with the provided name.
+//When value is a nil pointer to a interface, e.g. (*driver.Valuer)(nil), all the types implementing
+//the interface who are not known by the dialect itself are mapped to the sql type.
+//Registering the same type twice for a dialect replaces the previous mapping.
+func RegisterType(dialect string, value interface{}, sqlType string) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("dialect: RegisterType value is nil")
+	}
+
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	for i, mapping := range types[dialect] {
+		if mapping.goType == t {
+			types[dialect][i].sqlType = sqlType
+			return
+		}
+	}
+	types[dialect] = append(types[dialect], typeMapping{goType: t, sqlType: sqlType})
+}
+
+//resolveType finds the sql type for column, registered types take precedence over the types known by the dialect.
+//Registered interfaces are only used when the dialect does not know the type.
+func resolveType(dialect string, column interface{}, size int, known func(column interface{}, size int) string) (string, error) {
+	t := reflect.TypeOf(column)
+	if t == nil {
+		return "", fmt.Errorf("invalid sql type for %s (nil)", dialect)
+	}
+
+	typesMu.RLock()
+	mappings := types[dialect]
+	typesMu.RUnlock()
+
+	//exact registered types
+	for _, mapping := range mappings {
+		if mapping.goType == t || (t.Kind() == reflect.Ptr && mapping.goType == t.Elem()) {
+			return mapping.sqlType, nil
+		}
+	}
+
+	if sqlType := known(column, size); sqlType != "" {
+		return sqlType, nil
+	}
+
+	//registered interfaces
+	for _, mapping := range mappings {
+		if mapping.goType.Kind() == reflect.Interface && (t.Implements(mapping.goType) || reflect.PtrTo(t).Implements(mapping.goType)) {
+			return mapping.sqlType, nil
+		}
+	}
+
+	//named types of a basic kind (time.Duration, type Status string) are handled as the underlying type
+	if base, ok := baseValue(t); ok {
+		if sqlType := known(base, size); sqlType != "" {
+			return sqlType, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid sql type for %s (%s)", dialect, t)
+}
+
+//baseValue returns the zero value of the basic type underlying t
+func baseValue(t reflect.Type) (interface{}, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return false, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return int(0), true
+	case reflect.Int64:
+		return int64(0), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return uint(0), true
+	case reflect.Uint64:
+		return uint64(0), true
+	case reflect.Float32, reflect.Float64:
+		return float64(0), true
+	case reflect.String:
+		return "", true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return []byte(nil), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,326 @@
+package storm
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/mbict/storm/dialect"
+	. "gopkg.in/check.v1"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+//*** test suite setup ***/
+type goldenSuite struct{}
+
+var _ = Suite(&goldenSuite{})
+
+//newDialectStorm creates a storm instance without a connection, only usable for generating sql
+func newDialectStorm(c *C, driverName string) *Storm {
+	s := &Storm{
+		dialect: dialect.New(driverName),
+		tables:  make(map[reflect.Type]*table),
+	}
+	for _, i := range []interface{}{
+		(*Person)(nil),
+		(*Address)(nil),
+		(*Country)(nil),
+		(*Telephone)(nil),
+		(*testAllTypeStructure)(nil),
+		(*testJsonStructure)(nil),
+		(*testEmbedStructure)(nil),
+		(*testCompositeKey)(nil),
+		(*testUuidKey)(nil),
+		(*testNaturalKey)(nil),
+		(*testUpsertStructure)(nil),
+		(*testVersionedStructure)(nil),
+		(*testSoftDeleteStructure)(nil),
+		(*testSoftDeleteNote)(nil),
+		(*testTaggedCustomer)(nil),
+		(*testTag)(nil),
+		(*testAccount)(nil),
+		(*testTransfer)(nil),
+		(*testCategory)(nil),
+	} {
+		c.Assert(s.RegisterStructure(i), IsNil)
+	}
+	return s
+}
+
+//assertGolden compares the sql against the golden file testdata/<name>.sql
+//run the tests with -update to (re)write the golden files
+func assertGolden(c *C, name string, sql string) {
+	path := filepath.Join("testdata", name+".sql")
+	if *updateGolden {
+		c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+		c.Assert(ioutil.WriteFile(path, []byte(sql+"\n"), 0644), IsNil)
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(sql+"\n", Equals, string(expected), Commentf("golden file %s", path))
+}
+
+//goldenCases renders the same statements for every dialect
+var goldenCases = []struct {
+	name     string
+	generate func(c *C, db *Storm) string
+}{
+	{"select", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("name = ? AND id IN (?, ?)", "test", 1, 2).
+			Where("OptionalAddress.Country.name = 'what?'").
+			Order("id", DESC).
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_paging", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Order("name", ASC).
+			Limit(10).
+			Offset(20).
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_limit", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Limit(10).
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_offset", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Offset(20).
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_for_update", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("id = ?", 1).
+			ForUpdate().
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return sqlQuery
+	}},
+	{"select_dependent", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			DependentColumns("Address.Country").
+			Where("Address.line1 = ?", "test").
+			generateSelectSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_parent", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("person[optional_address].name = ?", "test").
+			generateSelectSQL(tableOf(c, db, (*Address)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_bool", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("bool = true OR (bool = FALSE AND string = 'true')").
+			generateSelectSQL(tableOf(c, db, (*testAllTypeStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_softdelete", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("name = ? OR notes.text = ?", "test", "test").
+			generateSelectSQL(tableOf(c, db, (*testSoftDeleteStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_unscoped", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Unscoped().
+			Where("notes.text = ?", "test").
+			generateSelectSQL(tableOf(c, db, (*testSoftDeleteStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_many2many", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("tags.name = ?", "test").
+			generateSelectSQL(tableOf(c, db, (*testTaggedCustomer)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_tagged_join", func(c *C, db *Storm) string {
+		sqlQuery, _, _, _, err := db.Query().
+			Where("target.name = ?", "test").
+			generateSelectSQL(tableOf(c, db, (*testTransfer)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"select_recursive", func(c *C, db *Storm) string {
+		tbl := tableOf(c, db, (*testCategory)(nil))
+		rel := tbl.findRelation("Children")
+		sqlQuery, _ := db.Query().generateRecursiveSQL(tbl, rel, rel.referencedKey(tbl), 1, 3)
+		return sqlQuery
+	}},
+	{"count", func(c *C, db *Storm) string {
+		sqlQuery, _, err := db.Query().
+			Where("telephones.number = ?", "111-11-1111").
+			generateCountSQL(tableOf(c, db, (*Person)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"insert", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateInsertSQL(reflect.ValueOf(Person{Name: "test", AddressId: 2}), tableOf(c, db, (*Person)(nil)))
+		return sqlQuery
+	}},
+	{"insert_preset_id", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateInsertSQL(reflect.ValueOf(Person{Id: 10, Name: "test", AddressId: 2}), tableOf(c, db, (*Person)(nil)))
+
+		//statements executed around the insert of explicit auto increment values
+		before, after := db.Dialect().PresetAutoIncrementSQL("person", "id")
+		return strings.TrimSpace(strings.Join([]string{before, sqlQuery, after}, "\n"))
+	}},
+	{"insert_all", func(c *C, db *Storm) string {
+		vs := []reflect.Value{reflect.ValueOf(Person{Name: "first", AddressId: 1}), reflect.ValueOf(Person{Name: "second", AddressId: 2})}
+		sqlQuery, _ := db.generateInsertAllSQL(vs, tableOf(c, db, (*Person)(nil)))
+		return sqlQuery
+	}},
+	{"upsert", func(c *C, db *Storm) string {
+		if !db.Dialect().Capabilities().Upsert {
+			return "unsupported"
+		}
+		tbl := tableOf(c, db, (*testUpsertStructure)(nil))
+		conflict, err := tbl.resolveColumns([]string{"email"})
+		c.Assert(err, IsNil)
+		update, err := tbl.resolveColumns([]string{"name", "score"})
+		c.Assert(err, IsNil)
+
+		sqlQuery, _ := db.generateUpsertSQL(reflect.ValueOf(testUpsertStructure{Email: "test", Name: "test"}), tbl, conflict, update)
+		return sqlQuery
+	}},
+	{"upsert_do_nothing", func(c *C, db *Storm) string {
+		if !db.Dialect().Capabilities().Upsert {
+			return "unsupported"
+		}
+		tbl := tableOf(c, db, (*testUpsertStructure)(nil))
+		sqlQuery, _ := db.generateUpsertSQL(reflect.ValueOf(testUpsertStructure{Id: 2, Email: "test"}), tbl, tbl.keys, nil)
+		return sqlQuery
+	}},
+	{"update", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateUpdateSQL(reflect.ValueOf(Person{Id: 3, Name: "test", AddressId: 2}), tableOf(c, db, (*Person)(nil)))
+		return sqlQuery
+	}},
+	{"update_columns", func(c *C, db *Storm) string {
+		tbl := tableOf(c, db, (*Person)(nil))
+		columns, err := tbl.resolveColumns([]string{"Name"})
+		c.Assert(err, IsNil)
+
+		sqlQuery, _ := db.generateUpdateSQL(reflect.ValueOf(Person{Id: 3, Name: "test", AddressId: 2}), tbl, columns...)
+		return sqlQuery
+	}},
+	{"update_version", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateUpdateSQL(reflect.ValueOf(testVersionedStructure{Id: 3, Name: "test", Version: 2}), tableOf(c, db, (*testVersionedStructure)(nil)))
+		return sqlQuery
+	}},
+	{"update_set", func(c *C, db *Storm) string {
+		sqlQuery, _, err := db.Where("line1 = ?", "test").
+			generateUpdateSQL(tableOf(c, db, (*Address)(nil)), Set{"country_id": 3, "Line2": "test"})
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"update_set_join", func(c *C, db *Storm) string {
+		sqlQuery, _, err := db.Where("person[address].name = ?", "test").
+			generateUpdateSQL(tableOf(c, db, (*Address)(nil)), Set{"country_id": 3})
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"delete", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateDeleteSQL(reflect.ValueOf(Person{Id: 3}), tableOf(c, db, (*Person)(nil)))
+		return sqlQuery
+	}},
+	{"delete_softdelete", func(c *C, db *Storm) string {
+		sqlQuery, _ := db.generateSoftDeleteSQL(reflect.ValueOf(testSoftDeleteStructure{Id: 3}), tableOf(c, db, (*testSoftDeleteStructure)(nil)))
+		return sqlQuery
+	}},
+	{"delete_set", func(c *C, db *Storm) string {
+		sqlQuery, _, err := db.Where("line1 = ?", "test").
+			generateDeleteSQL(tableOf(c, db, (*Address)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"delete_set_join", func(c *C, db *Storm) string {
+		sqlQuery, _, err := db.Where("country.name = ?", "test").
+			generateDeleteSQL(tableOf(c, db, (*Address)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testAllTypeStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table_json", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testJsonStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table_embed", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testEmbedStructure)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table_composite", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testCompositeKey)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table_uuid", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testUuidKey)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_table_natural_key", func(c *C, db *Storm) string {
+		sqlQuery, err := db.generateCreateTableSQL(tableOf(c, db, (*testNaturalKey)(nil)))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"create_join_table", func(c *C, db *Storm) string {
+		tbl := tableOf(c, db, (*testTag)(nil))
+		sqlQuery, err := db.generateCreateJoinTableSQL(tbl, tbl.findRelation("Customers"))
+		c.Assert(err, IsNil)
+		return sqlQuery
+	}},
+	{"update_composite", func(c *C, db *Storm) string {
+		entity := testCompositeKey{CustomerId: 1, TagId: 2, Position: 3}
+		sqlQuery, _ := db.generateUpdateSQL(reflect.ValueOf(entity), tableOf(c, db, (*testCompositeKey)(nil)))
+		return sqlQuery
+	}},
+	{"drop_table", func(c *C, db *Storm) string {
+		return db.generateDropTableSQL(tableOf(c, db, (*Person)(nil)))
+	}},
+}
+
+var goldenDialects = []string{"mssql", "mysql", "postgres", "sqlite3"}
+
+func tableOf(c *C, db *Storm, i interface{}) *table {
+	tbl, ok := db.table(reflect.TypeOf(i).Elem())
+	c.Assert(ok, Equals, true)
+	return tbl
+}
+
+/*** tests ***/
+func (s *goldenSuite) TestDialects(c *C) {
+	for _, driverName := range goldenDialects {
+		db := newDialectStorm(c, driverName)
+		for _, gc := range goldenCases {
+			assertGolden(c, driverName+"/"+gc.name, gc.generate(c, db))
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package storm
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+//KeyGenerator creates a new primary key value, invoked on insert when the key column is empty
+//The returned value is converted to the type of the key column
+type KeyGenerator func() (interface{}, error)
+
+var (
+	generatorsMu sync.RWMutex
+	generators   = map[string]KeyGenerator{
+		"uuid":   newUUIDv4,
+		"uuidv4": newUUIDv4,
+		"uuidv7": newUUIDv7,
+		"ulid":   newULID,
+	}
+)
+
+//RegisterGenerator makes a key generator available by the provided name for the `gen` column tag
+//Example:
+// storm.RegisterGenerator("snowflake", func() (interface{}, error) { return node.Generate().Int64(), nil })
+//
+// type Order struct {
+//	Id int64 `db:"pk,gen(snowflake)"`
+// }
+//If RegisterGenerator is called twice with the same name or if generator is nil, it panics.
+func RegisterGenerator(name string, generator KeyGenerator) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	if generator == nil {
+		panic("storm: RegisterGenerator generator is nil")
+	}
+	if _, dup := generators[name]; dup {
+		panic("storm: RegisterGenerator called twice for generator " + name)
+	}
+	generators[name] = generator
+}
+
+func keyGenerator(name string) (KeyGenerator, bool) {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+	generator, ok := generators[name]
+	return generator, ok
+}
+
+//binaryKey is a generated 128 bit key with its textual representation
+type binaryKey struct {
+	raw  [16]byte
+	text string
+}
+
+//newUUIDv4 generates a random uuid (RFC 4122 version 4)
+func newUUIDv4() (interface{}, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return nil, err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return binaryKey{raw: u, text: formatUUID(u)}, nil
+}
+
+//newUUIDv7 generates a time ordered uuid (RFC 9562 version 7)
+func newUUIDv7() (interface{}, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return nil, err
+	}
+	putTimestamp(u[:6], time.Now())
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+	return binaryKey{raw: u, text: formatUUID(u)}, nil
+}
+
+//newULID generates a lexicographically sortable identifier, 48 bits time and 80 bits randomness
+func newULID() (interface{}, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return nil, err
+	}
+	putTimestamp(u[:6], time.Now())
+	return binaryKey{raw: u, text: formatULID(u)}, nil
+}
+
+//putTimestamp writes the unix time in milliseconds as 48 bit big endian
+func putTimestamp(b []byte, t time.Time) {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(b, ts[2:])
+}
+
+func formatUUID(u [16]byte) string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf)
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+//formatULID encodes the 128 bits in 26 characters crockford base32, the first 2 bits are padding
+func formatULID(u [16]byte) string {
+	buf := make([]byte, 26)
+	for i := range buf {
+		var index byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			index <<= 1
+			if bit >= 0 {
+				index |= (u[bit/8] >> uint(7-bit%8)) & 1
+			}
+		}
+		buf[i] = crockfordAlphabet[index]
+	}
+	return string(buf)
+}
+
+//defaultKeySize bounds textual and binary keys without a size, unbounded (MAX, TEXT) columns cannot be a primary key
+const defaultKeySize = 255
+
+//keySize returns the default column size for a primary key, keys created by the builtin generators have a fixed size
+func keySize(generator string, t reflect.Type) int {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		switch generator {
+		case "uuid", "uuidv4", "uuidv7", "ulid":
+			return 16
+		}
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return defaultKeySize
+		}
+		return 0
+	}
+
+	switch generator {
+	case "uuid", "uuidv4", "uuidv7":
+		return 36
+	case "ulid":
+		return 26
+	}
+	if t.Kind() == reflect.String {
+		return defaultKeySize
+	}
+	return 0
+}
+
+//assignKey sets the generated key on the field, binary keys are stored raw in byte fields and textual in string fields
+func assignKey(field reflect.Value, key interface{}) error {
+	if bk, ok := key.(binaryKey); ok {
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(bk.text)
+			return nil
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+			field.SetBytes(append([]byte(nil), bk.raw[:]...))
+			return nil
+		case field.Kind() == reflect.Array && field.Len() == 16 && field.Type().Elem().Kind() == reflect.Uint8:
+			reflect.Copy(field, reflect.ValueOf(bk.raw[:]))
+			return nil
+		}
+		return fmt.Errorf("cannot assign generated key to type `%s`", field.Type())
+	}
+
+	v := reflect.ValueOf(key)
+	if !v.IsValid() || !v.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("cannot assign generated key of type `%T` to type `%s`", key, field.Type())
+	}
+	field.Set(v.Convert(field.Type()))
+	return nil
+}
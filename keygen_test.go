@@ -0,0 +1,98 @@
+package storm
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type keygenSuite struct{}
+
+var _ = Suite(&keygenSuite{})
+
+func (s *keygenSuite) TestUUIDv4(c *C) {
+	key, err := newUUIDv4()
+	c.Assert(err, IsNil)
+
+	bk := key.(binaryKey)
+	c.Assert(bk.text, Matches, "[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}")
+	c.Assert(bk.text, Equals, formatUUID(bk.raw))
+
+	other, _ := newUUIDv4()
+	c.Assert(other, Not(DeepEquals), key)
+}
+
+func (s *keygenSuite) TestUUIDv7(c *C) {
+	key, err := newUUIDv7()
+	c.Assert(err, IsNil)
+
+	bk := key.(binaryKey)
+	c.Assert(bk.text, Matches, "[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}")
+
+	//time ordered
+	time.Sleep(2 * time.Millisecond)
+	later, _ := newUUIDv7()
+	c.Assert(later.(binaryKey).text > bk.text, Equals, true)
+}
+
+func (s *keygenSuite) TestULID(c *C) {
+	key, err := newULID()
+	c.Assert(err, IsNil)
+	c.Assert(key.(binaryKey).text, Matches, "[0-7][0-9A-HJKMNP-TV-Z]{25}")
+
+	c.Assert(formatULID([16]byte{}), Equals, "00000000000000000000000000")
+	c.Assert(formatULID([16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), Equals, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	c.Assert(formatULID([16]byte{0x01, 0x56, 0x3d, 0xf3, 0x64, 0x81, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})[:10], Equals, "01ARYZ6S41")
+}
+
+func (s *keygenSuite) TestAssignKey(c *C) {
+	bk := binaryKey{raw: [16]byte{1, 2, 3}, text: "key"}
+
+	var str string
+	c.Assert(assignKey(reflect.ValueOf(&str).Elem(), bk), IsNil)
+	c.Assert(str, Equals, "key")
+
+	var b []byte
+	c.Assert(assignKey(reflect.ValueOf(&b).Elem(), bk), IsNil)
+	c.Assert(b, DeepEquals, bk.raw[:])
+
+	var u testUUID
+	c.Assert(assignKey(reflect.ValueOf(&u).Elem(), bk), IsNil)
+	c.Assert(u, DeepEquals, testUUID(bk.raw))
+
+	var i int64
+	c.Assert(assignKey(reflect.ValueOf(&i).Elem(), 12), IsNil)
+	c.Assert(i, Equals, int64(12))
+
+	c.Assert(assignKey(reflect.ValueOf(&i).Elem(), bk), ErrorMatches, "cannot assign generated key to type `int64`")
+	c.Assert(assignKey(reflect.ValueOf(&i).Elem(), "12"), ErrorMatches, "cannot assign generated key of type `string` to type `int64`")
+}
+
+//registerTestGenerators registers the test generators once, registering twice panics
+var (
+	registerTestGenerators sync.Once
+	testSequence           int64
+)
+
+func registerGenerators() {
+	registerTestGenerators.Do(func() {
+		RegisterGenerator("test_keygen_counter", func() (interface{}, error) { return 1, nil })
+		RegisterGenerator("test_storm_sequence", func() (interface{}, error) {
+			testSequence++
+			return testSequence, nil
+		})
+	})
+}
+
+func (s *keygenSuite) TestRegisterGenerator(c *C) {
+	registerGenerators()
+
+	generator, ok := keyGenerator("test_keygen_counter")
+	c.Assert(ok, Equals, true)
+	c.Assert(generator, NotNil)
+
+	c.Assert(func() { RegisterGenerator("test_keygen_counter", generator) }, PanicMatches, "storm: RegisterGenerator called twice for generator test_keygen_counter")
+	c.Assert(func() { RegisterGenerator("test_keygen_nil", nil) }, PanicMatches, "storm: RegisterGenerator generator is nil")
+}
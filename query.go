@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -42,6 +43,9 @@ type Query struct {
 	offset int
 	limit  int
 
+	forUpdate bool
+	unscoped  bool
+
 	dependentFetch   bool
 	dependentColumns []string
 
@@ -74,6 +78,8 @@ func newQuery(ctx Context, parent *Query) *Query {
 		q.order = parent.order
 		q.offset = parent.offset
 		q.limit = parent.limit
+		q.forUpdate = parent.forUpdate
+		q.unscoped = parent.unscoped
 	} else {
 		q.where = make([]where, 0)
 		q.order = make([]order, 0)
@@ -118,7 +124,7 @@ func (query *Query) Where(condition string, bindAttr ...interface{}) *Query {
 					bindVars = append(bindVars, v.FieldByIndex(tbl.aiColumn.goIndex).Int())
 					continue
 				} else if len(tbl.keys) >= 1 {
-					bindVars = append(bindVars, v.FieldByIndex(tbl.keys[0].goIndex).Int())
+					bindVars = append(bindVars, tbl.keys[0].bindValue(v))
 					continue
 				}
 			}
@@ -141,6 +147,19 @@ func (query *Query) Offset(offset int) *Query {
 	return query
 }
 
+//ForUpdate will lock the selected rows until the end of the transaction
+//A error is returned on select when the dialect does not support row locking
+func (query *Query) ForUpdate() *Query {
+	query.forUpdate = true
+	return query
+}
+
+//Unscoped will include the soft deleted records in the results
+func (query *Query) Unscoped() *Query {
+	query.unscoped = true
+	return query
+}
+
 //DependentColumns will set the dependent fetch mode for Find and First.
 //When set all or only the provided columns who are dependent will be populated when fetched
 func (query *Query) DependentColumns(columns ...string) *Query {
@@ -183,30 +202,62 @@ func (query *Query) Count(i interface{}) (int64, error) {
 	return query.fetchCount(i)
 }
 
-//Dependent will try to fetch all the related enities and populate the dependent fields (slice and single values)
-//You can provide a list with column names if you only want those fields to be populated
-func (query *Query) Dependent(i interface{}, columns ...string) error {
+//Set holds the new values of a set based Update, columns can be provided by their column or field name
+type Set map[string]interface{}
 
-	v := reflect.ValueOf(i)
-	if v.Kind() != reflect.Ptr {
-		return errors.New("provided input is not by reference")
+//Update will update the provided columns of all the records matching the query, the number of affected rows is returned
+//Conditions on related structures are resolved like a select
+//Example:
+// n, err := db.Where("person.name = ?", "x").Update((*Address)(nil), storm.Set{"country_id": 3})
+func (query *Query) Update(i interface{}, set Set) (int64, error) {
+	tbl, err := query.structureTable(i)
+	if err != nil {
+		return 0, err
 	}
 
-	v = v.Elem()
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return errors.New("Cannot get dependent fields on nil struct")
-		}
+	sqlQuery, bind, err := query.generateUpdateSQL(tbl, set)
+	if err != nil {
+		return 0, err
 	}
-	v = reflect.Indirect(v)
-	if v.Kind() != reflect.Struct || !v.CanSet() {
-		return errors.New("provided input is not a structure type")
+	return query.exec(sqlQuery, bind)
+}
+
+//Delete will delete all the records matching the query, the number of deleted rows is returned
+//Records with a soft delete column are marked as deleted instead, callbacks are not invoked
+//Example:
+// n, err := db.Where("person[address].name = ?", "x").Delete((*Address)(nil))
+func (query *Query) Delete(i interface{}) (int64, error) {
+	tbl, err := query.structureTable(i)
+	if err != nil {
+		return 0, err
 	}
 
-	//find the table
-	tbl, ok := query.ctx.table(v.Type())
-	if !ok {
-		return fmt.Errorf("no registered structure for `%s` found", v.Type().String())
+	if tbl.deletedColumn != nil {
+		return query.Update(i, Set{tbl.deletedColumn.columnName: query.ctx.Storm().clock()})
+	}
+	return query.HardDelete(i)
+}
+
+//HardDelete will delete all the records matching the query, also when they have a soft delete column
+func (query *Query) HardDelete(i interface{}) (int64, error) {
+	tbl, err := query.structureTable(i)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlQuery, bind, err := query.generateDeleteSQL(tbl)
+	if err != nil {
+		return 0, err
+	}
+	return query.exec(sqlQuery, bind)
+}
+
+//Dependent will try to fetch all the related enities and populate the dependent fields (slice and single values)
+//You can provide a list with column names if you only want those fields to be populated
+func (query *Query) Dependent(i interface{}, columns ...string) error {
+	v, tbl, err := query.dependentValue(i)
+	if err != nil {
+		return err
 	}
 
 	//group similar depends
@@ -239,6 +290,47 @@ func (query *Query) Dependent(i interface{}, columns ...string) error {
 	return nil
 }
 
+//DependentRecursive loads the subtrees of the self referencing relations created with Recursive
+func (query *Query) DependentRecursive(i interface{}, recursions ...Recursion) error {
+	v, tbl, err := query.dependentValue(i)
+	if err != nil {
+		return err
+	}
+
+	for _, recursion := range recursions {
+		if err = query.fetchRecursive(v, tbl, recursion.relation, recursion.maxDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//dependentValue resolves the structure and the table the dependent fields are populated on
+func (query *Query) dependentValue(i interface{}) (reflect.Value, *table, error) {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		return v, nil, errors.New("provided input is not by reference")
+	}
+
+	v = v.Elem()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, nil, errors.New("Cannot get dependent fields on nil struct")
+		}
+	}
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct || !v.CanSet() {
+		return v, nil, errors.New("provided input is not a structure type")
+	}
+
+	//find the table
+	tbl, ok := query.ctx.table(v.Type())
+	if !ok {
+		return v, nil, fmt.Errorf("no registered structure for `%s` found", v.Type().String())
+	}
+	return v, tbl, nil
+}
+
 func (query *Query) fetchRelatedColumn(v reflect.Value, tbl *table, rel *relation, depends []string) error {
 	elm := v.FieldByIndex(rel.goIndex)
 	dst := elm.Addr().Interface()
@@ -276,9 +368,18 @@ func (query *Query) fetchRelatedColumn(v reflect.Value, tbl *table, rel *relatio
 			}
 		}
 
-		err := query.ctx.Query().
+		relTbl, ok := query.ctx.table(typeIndirect(rel.goType))
+		if !ok {
+			return fmt.Errorf("no registered structure for `%s` found", typeIndirect(rel.goType))
+		}
+		relKey := rel.referencedKey(relTbl)
+		if relKey == nil {
+			return fmt.Errorf("cannot reference table `%s` without a single primary key", relTbl.tableName)
+		}
+
+		err := query.relatedQuery().
 			DependentColumns(depends...).
-			Where("id = ?", val).
+			Where(relKey.columnName+" = ?", val).
 			Find(dst)
 
 		if err == sql.ErrNoRows {
@@ -289,11 +390,39 @@ func (query *Query) fetchRelatedColumn(v reflect.Value, tbl *table, rel *relatio
 		} else if err != nil {
 			return err
 		}
+	} else if rel.joinTable != "" {
+		key := tbl.primaryKey()
+		relKey := rel.relTable.primaryKey()
+		if key == nil || relKey == nil {
+			return fmt.Errorf("cannot link tables `%s` and `%s` without a single primary key", tbl.tableName, rel.relTable.tableName)
+		}
+
+		ids, err := query.fetchJoinedKeys(rel, v.FieldByIndex(key.goIndex).Interface())
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			elm.SetLen(0)
+			return nil
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+		err = query.relatedQuery().
+			DependentColumns(depends...).
+			Where(fmt.Sprintf("%s IN (%s)", relKey.columnName, placeholders), ids...).
+			Find(dst)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
 	} else if rel.relColumn != nil && rel.relTable != nil {
-		val := v.FieldByIndex(tbl.aiColumn.goIndex).Interface()
-		err := query.ctx.Query().
+		key := rel.referencedKey(tbl)
+		if key == nil {
+			return fmt.Errorf("cannot reference table `%s` without a single primary key", tbl.tableName)
+		}
+		val := v.FieldByIndex(key.goIndex).Interface()
+		err := query.relatedQuery().
 			DependentColumns(depends...).
-			Where(tbl.tableName+"_id = ?", val).
+			Where(rel.relColumn.columnName+" = ?", val).
 			Find(dst)
 		if err != nil && err != sql.ErrNoRows {
 			return err
@@ -302,21 +431,82 @@ func (query *Query) fetchRelatedColumn(v reflect.Value, tbl *table, rel *relatio
 	return nil
 }
 
+//fetchJoinedKeys returns the keys of the related records linked in the join table
+func (query *Query) fetchJoinedKeys(rel *relation, key interface{}) ([]interface{}, error) {
+	d := query.ctx.Dialect()
+	sqlQuery := rebind(d, fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", d.Quote(rel.joinRelKey), d.Quote(rel.joinTable), d.Quote(rel.joinKey)))
+	if query.ctx.logger() != nil {
+		query.ctx.logger().Printf("`%s` binding : %v", sqlQuery, key)
+	}
+
+	rows, err := query.ctx.DB().Query(sqlQuery, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []interface{}
+	for rows.Next() {
+		var id interface{}
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//relatedQuery creates the query used to fetch the dependent structures, with the same scope
+func (query *Query) relatedQuery() *Query {
+	related := query.ctx.Query()
+	related.unscoped = query.unscoped
+	return related
+}
+
+//isKeyString checks if the string is a value of the string key column and not a condition
+func isKeyString(key *column, s string) bool {
+	return typeIndirect(key.goType).Kind() == reflect.String && !strings.ContainsAny(s, " =<>()?")
+}
+
+//isKeyTuple checks if the arguments are the values of the primary keys, strings must be values of a string key
+func isKeyTuple(keys []*column, where []interface{}) bool {
+	if len(keys) == 0 || len(where) != len(keys) {
+		return false
+	}
+	for i, key := range keys {
+		if s, ok := where[i].(string); ok && !isKeyString(key, s) {
+			return false
+		}
+	}
+	return true
+}
+
+//whereKeys adds a condition for every primary key, the values are in the order of the keys
+func (query *Query) whereKeys(tbl *table, values []interface{}) {
+	for i, key := range tbl.keys {
+		query.Where(fmt.Sprintf("%s = ?", key.columnName), values[i])
+	}
+}
+
 //create additional where stements from arguments
 func (query *Query) applyWhere(tbl *table, where ...interface{}) error {
 	switch t := where[0].(type) {
 	case string:
+		//a (natural) primary key tuple starting with a string, unless the string is a condition
+		if isKeyTuple(tbl.keys, where) {
+			query.whereKeys(tbl, where)
+			return nil
+		}
 		query.Where(t, where[1:]...)
 	case int, int8, int16, int32, uint, uint8, uint16, uint32, int64, uint64, sql.NullInt64:
-		if len(tbl.keys) == 1 {
-			if len(where) == 1 {
-				query.Where(fmt.Sprintf("%s = ?", tbl.keys[0].columnName), where...)
-			} else {
-				return errors.New("not implemented having multiple pk values for find")
-			}
-		} else {
-			return errors.New("not implemented having multiple pks for find")
+		//find by the primary key (tuple)
+		if len(tbl.keys) == 0 {
+			return errors.New("no primary key defined for find")
+		}
+		if len(where) != len(tbl.keys) {
+			return fmt.Errorf("expected %d primary key values for find, got %d", len(tbl.keys), len(where))
 		}
+		query.whereKeys(tbl, where)
 	default:
 		v := reflect.Indirect(reflect.ValueOf(t))
 		if v.Kind() == reflect.Struct {
@@ -326,31 +516,59 @@ func (query *Query) applyWhere(tbl *table, where ...interface{}) error {
 					query.Where(condition, v.FieldByIndex(tbl.aiColumn.goIndex).Int())
 					return nil
 				} else if len(tbl.keys) >= 1 {
-					query.Where(condition, v.FieldByIndex(tbl.keys[0].goIndex).Int())
+					query.Where(condition, tbl.keys[0].bindValue(v))
 					return nil
 				}
 			}
 		}
+
+		//value of the same type as a single (non numeric) primary key
+		if len(tbl.keys) == 1 && len(where) == 1 && reflect.TypeOf(t) == tbl.keys[0].goType {
+			query.Where(fmt.Sprintf("%s = ?", tbl.keys[0].columnName), t)
+			return nil
+		}
 		return errors.New("unsupported pk find type")
 	}
 	return nil
 }
 
-//fetch a single row into a element
-func (query *Query) fetchCount(i interface{}) (cnt int64, err error) {
+//structureTable returns the table of the provided structure type
+func (query *Query) structureTable(i interface{}) (*table, error) {
 	t := reflect.TypeOf(i)
-	if t.Kind() == reflect.Ptr {
+	if t != nil && t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
-	if t.Kind() != reflect.Struct {
-		return 0, errors.New("provided input is not a structure type")
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("provided input is not a structure type")
 	}
 
 	//find the table
 	tbl, ok := query.ctx.table(t)
 	if !ok {
-		return 0, fmt.Errorf("no registered structure for `%s` found", t)
+		return nil, fmt.Errorf("no registered structure for `%s` found", t)
+	}
+	return tbl, nil
+}
+
+//exec executes the statement and returns the number of affected rows
+func (query *Query) exec(sqlQuery string, bind []interface{}) (int64, error) {
+	if query.ctx.logger() != nil {
+		query.ctx.logger().Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	res, err := query.ctx.DB().Exec(sqlQuery, bind...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+//fetch a single row into a element
+func (query *Query) fetchCount(i interface{}) (cnt int64, err error) {
+	tbl, err := query.structureTable(i)
+	if err != nil {
+		return 0, err
 	}
 
 	//generate sql and prepare
@@ -433,22 +651,25 @@ func (query *Query) fetchRow(i interface{}, where ...interface{}) (err error) {
 	//create scan destination
 	dest := make([]interface{}, len(tbl.columns))
 	for key, col := range tbl.columns {
-		dest[key] = v.FieldByIndex(col.goIndex).Addr().Interface()
+		dest[key] = col.scanDest(v)
 	}
 
 	//create dependent scan destination
-	for _, scanObj := range scanObjects {
-		vc := reflect.New(scanObj.tbl.goType)
-
-		//find path and assign
+	scanned := make([]reflect.Value, len(scanObjects))
+	for i, scanObj := range scanObjects {
+		//find path and assign, value relations are scanned in place
 		target := reflect.Indirect(v).FieldByIndex(scanObj.index[0])
 		for _, path := range scanObj.index[1:] {
-			target = target.Elem().FieldByIndex(path)
+			target = reflect.Indirect(target).FieldByIndex(path)
 		}
-		target.Set(vc)
+		if target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(scanObj.tbl.goType))
+			target = target.Elem()
+		}
+		scanned[i] = target
 
 		for _, col := range scanObj.tbl.columns {
-			dest = append(dest, vc.Elem().FieldByIndex(col.goIndex).Addr().Interface())
+			dest = append(dest, col.scanDest(target))
 		}
 	}
 
@@ -458,6 +679,11 @@ func (query *Query) fetchRow(i interface{}, where ...interface{}) (err error) {
 		return err
 	}
 
+	//remember the loaded values of tracked structures
+	if err = takeSnapshots(v, tbl, scanned, scanObjects); err != nil {
+		return err
+	}
+
 	if err = tbl.callbacks.invoke(v.Addr(), "OnInit", query.ctx); err != nil {
 		return err
 	}
@@ -479,7 +705,7 @@ func (query *Query) fetchRow(i interface{}, where ...interface{}) (err error) {
 			for _, index := range depend.index[:len(depend.index)-1] {
 				vTarget = vTarget.Elem().FieldByIndex(index)
 				if currentTbl, ok = query.ctx.table(typeIndirect(vTarget.Type())); !ok {
-					return fmt.Errorf("Depend cannot find table, not registered %s, %s", typeIndirect(vTarget.Type()))
+					return fmt.Errorf("Depend cannot find table, not registered %s", typeIndirect(vTarget.Type()))
 				}
 			}
 
@@ -580,7 +806,7 @@ func (query *Query) fetchAll(i interface{}, where ...interface{}) (err error) {
 						for _, index := range depend.index[:len(depend.index)-1] {
 							vTarget = vTarget.Elem().FieldByIndex(index)
 							if currentTbl, ok = query.ctx.table(typeIndirect(vTarget.Type())); !ok {
-								return fmt.Errorf("Depend cannot find table, not registered %s, %s", typeIndirect(vTarget.Type()))
+								return fmt.Errorf("Depend cannot find table, not registered %s", typeIndirect(vTarget.Type()))
 							}
 						}
 
@@ -598,22 +824,25 @@ func (query *Query) fetchAll(i interface{}, where ...interface{}) (err error) {
 		//create scan destination
 		dest := make([]interface{}, len(tbl.columns))
 		for key, col := range tbl.columns {
-			dest[key] = v.Elem().FieldByIndex(col.goIndex).Addr().Interface()
+			dest[key] = col.scanDest(v.Elem())
 		}
 
 		//create dependent scan destination
-		for _, scanObj := range scanObjects {
-			vc := reflect.New(scanObj.tbl.goType)
-
-			//find path and assign
+		scanned := make([]reflect.Value, len(scanObjects))
+		for i, scanObj := range scanObjects {
+			//find path and assign, value relations are scanned in place
 			target := v.Elem().FieldByIndex(scanObj.index[0])
 			for _, path := range scanObj.index[1:] {
-				target = target.Elem().FieldByIndex(path)
+				target = reflect.Indirect(target).FieldByIndex(path)
+			}
+			if target.Kind() == reflect.Ptr {
+				target.Set(reflect.New(scanObj.tbl.goType))
+				target = target.Elem()
 			}
-			target.Set(vc)
+			scanned[i] = target
 
 			for _, col := range scanObj.tbl.columns {
-				dest = append(dest, vc.Elem().FieldByIndex(col.goIndex).Addr().Interface())
+				dest = append(dest, col.scanDest(target))
 			}
 		}
 
@@ -622,6 +851,11 @@ func (query *Query) fetchAll(i interface{}, where ...interface{}) (err error) {
 			return err
 		}
 
+		//remember the loaded values of tracked structures
+		if err = takeSnapshots(v.Elem(), tbl, scanned, scanObjects); err != nil {
+			return err
+		}
+
 		if sliceTypeIsPtr == true {
 			vs.Set(reflect.Append(vs, v))
 		} else {
@@ -639,6 +873,7 @@ func (query *Query) generateSelectSQL(tbl *table) (string, []interface{}, []depe
 	if err != nil {
 		return "", nil, nil, nil, err
 	}
+	statements[0] = query.scopeWhere(tbl, statements[0])
 
 	//resolve depends and column binder
 	columnsSQL, dependsJoins, remainingDepends, scanObjects := query.resolveDependsAndColumns(tbl)
@@ -648,19 +883,20 @@ func (query *Query) generateSelectSQL(tbl *table) (string, []interface{}, []depe
 	sql := bytes.NewBufferString(fmt.Sprintf("SELECT %s FROM %s AS %s%s%s%s", columnsSQL, tblName, tblName, joins, dependsJoins, statements[0]))
 
 	if query.groupby {
-		sql.WriteString(fmt.Sprintf(" GROUP BY %s.%s", tblName, query.ctx.Dialect().Quote(tbl.aiColumn.columnName)))
+		sql.WriteString(" GROUP BY " + query.generateKeyColumns(tbl))
 	}
 	sql.WriteString(statements[1]) //optional order by
 
-	if query.limit > 0 {
-		sql.WriteString(fmt.Sprintf(" LIMIT %d", query.limit))
-	}
+	sql.WriteString(query.ctx.Dialect().Paging(query.limit, query.offset, len(query.order) > 0)) //optional limit and offset
 
-	if query.offset > 0 {
-		sql.WriteString(fmt.Sprintf(" OFFSET %d", query.offset))
+	if query.forUpdate {
+		if !query.ctx.Dialect().Capabilities().ForUpdate {
+			return "", nil, nil, nil, errors.New("dialect does not support `FOR UPDATE` row locking")
+		}
+		sql.WriteString(" FOR UPDATE")
 	}
 
-	return sql.String(), bindVars, remainingDepends, scanObjects, err
+	return rebind(query.ctx.Dialect(), sql.String()), bindVars, remainingDepends, scanObjects, err
 }
 
 func (query *Query) generateCountSQL(tbl *table) (string, []interface{}, error) {
@@ -670,13 +906,145 @@ func (query *Query) generateCountSQL(tbl *table) (string, []interface{}, error)
 	if nil != err {
 		return "", nil, err
 	}
+	statements[0] = query.scopeWhere(tbl, statements[0])
 
 	//write the query
 	tblName := query.ctx.Dialect().Quote(tbl.tableName)
 	if query.groupby {
-		return fmt.Sprintf("SELECT COUNT(DISTINCT %s.%s) FROM %s AS %s%s%s", tblName, query.ctx.Dialect().Quote(tbl.aiColumn.columnName), tblName, tblName, joins, statements[0]), bindVars, nil
+		if len(tbl.keys) > 1 {
+			//distinct on multiple columns is not portable, count the grouped keys instead
+			return rebind(query.ctx.Dialect(), fmt.Sprintf("SELECT COUNT(*) FROM (SELECT %s FROM %s AS %s%s%s GROUP BY %s) AS %s", query.generateKeyColumns(tbl), tblName, tblName, joins, statements[0], query.generateKeyColumns(tbl), query.ctx.Dialect().Quote("grouped"))), bindVars, nil
+		}
+		return rebind(query.ctx.Dialect(), fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s AS %s%s%s", query.generateKeyColumns(tbl), tblName, tblName, joins, statements[0])), bindVars, nil
 	}
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s AS %s%s%s", tblName, tblName, joins, statements[0]), bindVars, nil
+	return rebind(query.ctx.Dialect(), fmt.Sprintf("SELECT COUNT(*) FROM %s AS %s%s%s", tblName, tblName, joins, statements[0])), bindVars, nil
+}
+
+//generateUpdateSQL creates the set based update, a subquery on the primary key is used when the conditions need joins
+func (query *Query) generateUpdateSQL(tbl *table, set Set) (string, []interface{}, error) {
+	if len(set) == 0 {
+		return "", nil, errors.New("no columns provided to update")
+	}
+
+	//sort the columns to get a stable statement
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		d         = query.ctx.Dialect()
+		sqlQuery  bytes.Buffer
+		bind      = make([]interface{}, 0, len(set))
+		assigned  = make([]*column, 0, len(set))
+		tblName   = d.Quote(tbl.tableName)
+		separator = ""
+	)
+	sqlQuery.WriteString(fmt.Sprintf("UPDATE %s SET ", tblName))
+	for _, name := range names {
+		col := tbl.findColumn(name)
+		if col == nil {
+			return "", nil, fmt.Errorf("unknown column `%s` in table `%s`", name, tbl.tableName)
+		}
+		sqlQuery.WriteString(fmt.Sprintf("%s%s = ?", separator, d.Quote(col.columnName)))
+
+		//json columns are encoded like the column value of a entity
+		value := set[name]
+		if col.isJSON {
+			value = jsonValue{reflect.ValueOf(value)}
+		}
+		bind = append(bind, value)
+		assigned = append(assigned, col)
+		separator = ", "
+	}
+
+	//the updated timestamp and version are maintained like a entity update
+	if tbl.updatedColumn != nil && !containsColumn(assigned, tbl.updatedColumn) {
+		sqlQuery.WriteString(fmt.Sprintf(", %s = ?", d.Quote(tbl.updatedColumn.columnName)))
+		bind = append(bind, query.ctx.Storm().clock())
+	}
+	if tbl.versionColumn != nil && !containsColumn(assigned, tbl.versionColumn) {
+		version := d.Quote(tbl.versionColumn.columnName)
+		sqlQuery.WriteString(fmt.Sprintf(", %s = %s + 1", version, version))
+	}
+
+	condition, conditionBind, err := query.generateCondition(tbl)
+	if err != nil {
+		return "", nil, err
+	}
+	sqlQuery.WriteString(condition)
+
+	return rebind(d, sqlQuery.String()), append(bind, conditionBind...), nil
+}
+
+//generateCondition creates the where clause of a set based statement on the table
+func (query *Query) generateCondition(tbl *table) (string, []interface{}, error) {
+	where, joins, bindVars, err := query.resolveCondition(tbl)
+	if err != nil {
+		return "", nil, err
+	}
+	if joins == "" {
+		return where, bindVars, nil
+	}
+
+	where, err = query.generateKeyCondition(tbl, where, joins)
+	return where, bindVars, err
+}
+
+//resolveCondition resolves the where statement and the joins needed by the conditions
+func (query *Query) resolveCondition(tbl *table) (string, string, []interface{}, error) {
+	where, bindVars := query.generateWhere()
+	statements, joins, err := query.formatAndResolveStatement(tbl, where)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return query.scopeWhere(tbl, statements[0]), joins, bindVars, nil
+}
+
+//generateKeyCondition matches the records by their primary key in a subquery with the joins
+//The subquery is wrapped in a derived table as mysql cannot select from the table it modifies
+func (query *Query) generateKeyCondition(tbl *table, where string, joins string) (string, error) {
+	key := tbl.primaryKey()
+	if key == nil {
+		return "", fmt.Errorf("cannot resolve the conditions on table `%s` without a single primary key", tbl.tableName)
+	}
+
+	d := query.ctx.Dialect()
+	tblName := d.Quote(tbl.tableName)
+	matched := d.Quote("matched")
+	return fmt.Sprintf(" WHERE %s IN (SELECT %s.%s FROM (SELECT %s.%s FROM %s AS %s%s%s) AS %s)", d.Quote(key.columnName), matched, d.Quote(key.columnName), tblName, d.Quote(key.columnName), tblName, tblName, joins, where, matched), nil
+}
+
+//generateDeleteSQL creates the set based delete, joins are rendered in the delete statement when the dialect supports it
+func (query *Query) generateDeleteSQL(tbl *table) (string, []interface{}, error) {
+	where, joins, bindVars, err := query.resolveCondition(tbl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	d := query.ctx.Dialect()
+	tblName := d.Quote(tbl.tableName)
+	if joins != "" {
+		if d.Capabilities().DeleteJoin {
+			return rebind(d, fmt.Sprintf("DELETE %s FROM %s AS %s%s%s", tblName, tblName, tblName, joins, where)), bindVars, nil
+		}
+
+		if where, err = query.generateKeyCondition(tbl, where, joins); err != nil {
+			return "", nil, err
+		}
+	}
+	return rebind(d, fmt.Sprintf("DELETE FROM %s%s", tblName, where)), bindVars, nil
+}
+
+//generateKeyColumns returns the primary key columns of the table, separated by a comma
+func (query *Query) generateKeyColumns(tbl *table) string {
+	d := query.ctx.Dialect()
+	keys := make([]string, len(tbl.keys))
+	for i, col := range tbl.keys {
+		keys[i] = d.Quote(tbl.tableName) + "." + d.Quote(col.columnName)
+	}
+	return strings.Join(keys, ", ")
 }
 
 func (query *Query) generateWhere() (string, []interface{}) {
@@ -722,8 +1090,41 @@ func (query *Query) generateOrder() string {
 var (
 	reExtract       = regexp.MustCompile("'.*'|([0-9A-Za-z\\][_\\-]+\\.)*[0-9A-Za-z_\\-]+")
 	reReservedWords = regexp.MustCompile("^(ASC|DESC|ORDER|GROUP|BY|AS|WHERE|IN|NOT|COUNT|NULL|MAX|MIN|AND|OR|RAND|RANDOM|\\-?\\d+(.\\d+)?)$")
+	reBoolLiterals  = regexp.MustCompile("^(?i:(TRUE)|FALSE)$")
 )
 
+//generateJoin creates the join statement for table tbl as alias on the left (alias.column) and right (joinAlias.column) side
+//Soft deleted records of the joined table are excluded
+func (query *Query) generateJoin(tbl *table, joinAlias string, leftAlias string, leftColumn string, rightColumn string) string {
+	d := query.ctx.Dialect()
+	join := fmt.Sprintf(" JOIN %s AS %s ON %s.%s = %s.%s", d.Quote(tbl.tableName), d.Quote(joinAlias), d.Quote(leftAlias), d.Quote(leftColumn), d.Quote(joinAlias), d.Quote(rightColumn))
+	if condition := query.softDeleteCondition(tbl, joinAlias); condition != "" {
+		join = join + " AND " + condition
+	}
+	return join
+}
+
+//softDeleteCondition returns the condition excluding the soft deleted records of the table, empty when not applicable
+func (query *Query) softDeleteCondition(tbl *table, alias string) string {
+	if tbl.deletedColumn == nil || query.unscoped {
+		return ""
+	}
+	d := query.ctx.Dialect()
+	return fmt.Sprintf("%s.%s IS NULL", d.Quote(alias), d.Quote(tbl.deletedColumn.columnName))
+}
+
+//scopeWhere adds the soft delete condition of the table to the resolved where statement
+func (query *Query) scopeWhere(tbl *table, where string) string {
+	condition := query.softDeleteCondition(tbl, tbl.tableName)
+	if condition == "" {
+		return where
+	}
+	if where == "" {
+		return " WHERE " + condition
+	}
+	return " WHERE " + condition + " AND (" + strings.TrimPrefix(where, " WHERE ") + ")"
+}
+
 func (query *Query) formatAndResolveStatement(tbl *table, ins ...string) ([]string, string, error) {
 	query.joins = make(map[string]*table)
 	var (
@@ -741,6 +1142,15 @@ func (query *Query) formatAndResolveStatement(tbl *table, ins ...string) ([]stri
 				continue
 			}
 
+			//boolean literals are written in the notation of the dialect
+			if boolMatch := reBoolLiterals.FindStringSubmatch(tmp); boolMatch != nil {
+				replacement := query.ctx.Dialect().Bool(boolMatch[1] != "")
+
+				in = in[:match[0]+offsetCorrection] + replacement + in[match[1]+offsetCorrection:]
+				offsetCorrection = offsetCorrection + (len(replacement) - (match[1] - match[0]))
+				continue
+			}
+
 			parts := strings.Split(tmp, ".")
 			colName := camelToSnake(parts[len(parts)-1])
 			targetTbl := tbl
@@ -803,8 +1213,12 @@ func (query *Query) formatAndResolveStatement(tbl *table, ins ...string) ([]stri
 
 						//only create join when not found
 						if _, ok := query.joins[nextAlias]; !ok {
+							key := rel.referencedKey(targetTbl)
+							if key == nil {
+								return nil, "", fmt.Errorf("Cannot join table `%s` without a single primary key in statement `%s`", targetTbl.tableName, tmp)
+							}
 							query.joins[nextAlias] = joinTbl
-							joinSQL = joinSQL + " JOIN " + joinTbl.tableName + " AS " + nextAlias + " ON " + alias + ".id = " + nextAlias + "." + rel.relColumn.columnName
+							joinSQL = joinSQL + query.generateJoin(joinTbl, nextAlias, alias, key.columnName, rel.relColumn.columnName)
 
 							//joining a parent table many to one, need to add a group here
 							query.groupby = true
@@ -818,15 +1232,35 @@ func (query *Query) formatAndResolveStatement(tbl *table, ins ...string) ([]stri
 							query.groupby = true
 
 							if _, ok := query.joins[nextAlias]; !ok { //only create join when not found
+								key := rel.referencedKey(targetTbl)
+								if key == nil {
+									return nil, "", fmt.Errorf("Cannot join table `%s` without a single primary key in statement `%s`", targetTbl.tableName, tmp)
+								}
 								query.joins[nextAlias] = joinTbl
-								joinSQL = joinSQL + " JOIN " + joinTbl.tableName + " AS " + nextAlias + " ON " + alias + ".id = " + nextAlias + "." + targetTbl.tableName + "_id"
+
+								if rel.joinTable != "" {
+									//many to many, join the join table first
+									relKey := joinTbl.primaryKey()
+									if relKey == nil {
+										return nil, "", fmt.Errorf("Cannot join table `%s` without a single primary key in statement `%s`", joinTbl.tableName, tmp)
+									}
+									linkAlias := nextAlias + "_link"
+									joinSQL = joinSQL + query.generateJoin(&table{tableName: rel.joinTable}, linkAlias, alias, key.columnName, rel.joinKey)
+									joinSQL = joinSQL + query.generateJoin(joinTbl, nextAlias, linkAlias, rel.joinRelKey, relKey.columnName)
+								} else {
+									joinSQL = joinSQL + query.generateJoin(joinTbl, nextAlias, alias, key.columnName, rel.relColumn.columnName)
+								}
 							}
 
 						case reflect.Struct:
 							//normal one to one
 							if _, ok := query.joins[nextAlias]; !ok { //only create join when not found
+								key := rel.referencedKey(joinTbl)
+								if key == nil {
+									return nil, "", fmt.Errorf("Cannot join table `%s` without a single primary key in statement `%s`", joinTbl.tableName, tmp)
+								}
 								query.joins[nextAlias] = joinTbl
-								joinSQL = joinSQL + " JOIN " + joinTbl.tableName + " AS " + nextAlias + " ON " + alias + "." + rel.relColumn.columnName + " = " + nextAlias + ".id"
+								joinSQL = joinSQL + query.generateJoin(joinTbl, nextAlias, alias, rel.relColumn.columnName, key.columnName)
 							}
 						}
 						alias = nextAlias
@@ -949,13 +1383,14 @@ func (query *Query) resolveDependsAndColumns(tbl *table) (columnsSQL string, joi
 			//create join if not already one
 			if _, ok := query.joins[nextAlias]; !ok {
 				//we assume scanner valuer are optional and ptr types of ints, we do not include them in this query
-				if rel.relColumn.isScanner == true || rel.relColumn.goType.Kind() == reflect.Ptr {
+				//soft deleted records would exclude the owning record from the results, so they are fetched separately as well
+				if rel.relColumn.isScanner == true || rel.relColumn.goType.Kind() == reflect.Ptr || rel.referencedKey(joinTbl) == nil || query.softDeleteCondition(joinTbl, nextAlias) != "" {
 					//optional joins are fetched in a separate depends call
 					addRemaningDepend(scanPath, strings.Join(parts[i+1:], "."), rel)
 					break
 				}
 
-				joinSQL = joinSQL + " JOIN " + joinTbl.tableName + " AS " + nextAlias + " ON " + alias + "." + rel.relColumn.columnName + " = " + nextAlias + ".id"
+				joinSQL = joinSQL + query.generateJoin(joinTbl, nextAlias, alias, rel.relColumn.columnName, rel.referencedKey(joinTbl).columnName)
 				query.joins[nextAlias] = joinTbl
 			}
 
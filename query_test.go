@@ -1138,6 +1138,30 @@ func (s *querySuite) Test_Find_Slice_Where(c *C) {
 		onInitInvoked:     true})
 }
 
+//offset without a limit
+func (s *querySuite) Test_Find_Slice_Offset(c *C) {
+	var persons []*Person
+	err := s.db.Query().
+		Order("id", ASC).
+		Offset(2).
+		Find(&persons)
+
+	c.Assert(err, IsNil)
+	c.Assert(persons, HasLen, 2)
+	c.Assert(persons[0].Id, Equals, 3)
+	c.Assert(persons[1].Id, Equals, 4)
+}
+
+//sqlite has no row locking
+func (s *querySuite) Test_Find_Slice_ForUpdateErrorNotSupported(c *C) {
+	var persons []*Person
+	err := s.db.Query().
+		ForUpdate().
+		Find(&persons)
+
+	c.Assert(err, ErrorMatches, "dialect does not support `FOR UPDATE` row locking")
+}
+
 //inline on id
 func (s *querySuite) Test_Find_Slice_Where_Inline(c *C) {
 	var persons []*Person
@@ -1656,6 +1680,99 @@ func (s *querySuite) Test_Find_Slice_ErrorSqlError(c *C) {
 	c.Assert(s.db.Query().Find(&inputs), ErrorMatches, "no such table: no_table")
 }
 
+/**************************************************************************
+ * Tests Update (set based)
+ **************************************************************************/
+func (s *querySuite) Test_Update(c *C) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	n, err := tx.Where("line1 = ?", "address 1 line 1").Update((*Address)(nil), Set{"country_id": 3, "Line2": "updated"})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(1))
+
+	var address *Address
+	c.Assert(tx.Find(&address, 1), IsNil)
+	c.Assert(address.CountryId, Equals, 3)
+	c.Assert(address.Line2, Equals, "updated")
+
+	c.Assert(tx.Find(&address, 2), IsNil)
+	c.Assert(address.CountryId, Equals, 2)
+}
+
+func (s *querySuite) Test_Update_WhereAutoJoin(c *C) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	n, err := tx.Where("country.name = ?", "nl").Update((*Address)(nil), Set{"line2": "dutch"})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(2))
+
+	cnt, err := tx.Where("line2 = ?", "dutch").Count((*Address)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(2))
+
+	//reversed join to the parent
+	n, err = tx.Where("person[address].name = ?", "person 1").Update((*Address)(nil), Set{"country_id": 4})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(1))
+
+	var address *Address
+	c.Assert(tx.Find(&address, 1), IsNil)
+	c.Assert(address.CountryId, Equals, 4)
+}
+
+func (s *querySuite) Test_Update_Error(c *C) {
+	_, err := s.db.Query().Update((*Address)(nil), Set{"unknown": 1})
+	c.Assert(err, ErrorMatches, "unknown column `unknown` in table `address`")
+
+	_, err = s.db.Query().Update((*Address)(nil), Set{})
+	c.Assert(err, ErrorMatches, "no columns provided to update")
+
+	_, err = s.db.Query().Update((*testStructure)(nil), Set{"name": "test"})
+	c.Assert(err, ErrorMatches, "no registered structure for `storm.testStructure` found")
+
+	_, err = s.db.Where("unknown.name = ?", 1).Update((*Address)(nil), Set{"line1": "test"})
+	c.Assert(err, ErrorMatches, "Cannot resolve table `unknown` in statement `unknown.name`")
+}
+
+/**************************************************************************
+ * Tests Delete (set based)
+ **************************************************************************/
+func (s *querySuite) Test_Delete(c *C) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	n, err := tx.Where("line1 = ?", "address 1 line 1").Delete((*Address)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(1))
+
+	var address *Address
+	c.Assert(tx.Find(&address, 1), Equals, sql.ErrNoRows)
+	c.Assert(tx.Find(&address, 2), IsNil)
+}
+
+func (s *querySuite) Test_Delete_WhereAutoJoin(c *C) {
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	n, err := tx.Where("country.name = ?", "nl").Delete((*Address)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(2))
+
+	cnt, err := tx.Query().Count((*Address)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(3))
+}
+
+func (s *querySuite) Test_Delete_Error(c *C) {
+	_, err := s.db.Query().Delete((*testStructure)(nil))
+	c.Assert(err, ErrorMatches, "no registered structure for `storm.testStructure` found")
+
+	_, err = s.db.Where("unknown.name = ?", 1).Delete((*Address)(nil))
+	c.Assert(err, ErrorMatches, "Cannot resolve table `unknown` in statement `unknown.name`")
+}
+
 /**************************************************************************
  * Tests generateSelectSQL (helper)
  **************************************************************************/
@@ -1697,7 +1814,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoin(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 1)
 	c.Assert(sql, Equals, "SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
 		"WHERE `person_optional_address`.`line1` = ?")
 }
 
@@ -1711,8 +1828,8 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinDeep(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 1)
 	c.Assert(sql, Equals, "SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN country AS person_optional_address_country ON person_optional_address.country_id = person_optional_address_country.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `country` AS `person_optional_address_country` ON `person_optional_address`.`country_id` = `person_optional_address_country`.`id` "+
 		"WHERE `person_optional_address_country`.`id` = ?")
 }
 
@@ -1726,7 +1843,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinOrderBy(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 0)
 	c.Assert(sql, Equals, "SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
 		"ORDER BY `person_optional_address`.`line1` ASC")
 }
 
@@ -1746,10 +1863,10 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinComplex(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 7)
 	c.Assert(sql, Equals, "SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` "+
-		"JOIN address AS person_address ON person.address_id = person_address.id "+
-		"JOIN country AS person_address_country ON person_address.country_id = person_address_country.id "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN country AS person_optional_address_country ON person_optional_address.country_id = person_optional_address_country.id "+
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` "+
+		"JOIN `country` AS `person_address_country` ON `person_address`.`country_id` = `person_address_country`.`id` "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `country` AS `person_optional_address_country` ON `person_optional_address`.`country_id` = `person_optional_address_country`.`id` "+
 		"WHERE `person`.`id` = ? AND `person`.`name` = ? AND `person_address_country`.`id` = ? AND `person_optional_address`.`line1` = ? AND "+
 		"`person_optional_address_country`.`id` = ? AND `person_optional_address_country`.`id` = ? AND `person_address`.`line1` = ?")
 }
@@ -1765,7 +1882,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinMany(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 4)
 	c.Assert(sql, Equals, "SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` "+
-		"JOIN telephone AS person_telephones ON person.id = person_telephones.person_id "+
+		"JOIN `telephone` AS `person_telephones` ON `person`.`id` = `person_telephones`.`person_id` "+
 		"WHERE `person_telephones`.`number` = ? AND `person_telephones`.`id` IN (?,?,?) "+
 		"GROUP BY `person`.`id`")
 }
@@ -1782,7 +1899,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinReverseToParent(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 3)
 	c.Assert(sql, Equals, "SELECT `country`.`id`, `country`.`name` FROM `country` AS `country` "+
-		"JOIN address AS country_address_country ON country.id = country_address_country.country_id "+
+		"JOIN `address` AS `country_address_country` ON `country`.`id` = `country_address_country`.`country_id` "+
 		"WHERE `country`.`id` = ? AND `country`.`name` = ? AND `country_address_country`.`line1` = ? "+
 		"GROUP BY `country`.`id`")
 }
@@ -1799,7 +1916,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinReverseToParentFirstOcc
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 2)
 	c.Assert(sql, Equals, "SELECT `address`.`id`, `address`.`line1`, `address`.`line2`, `address`.`country_id` FROM `address` AS `address` "+
-		"JOIN person AS address_person_address ON address.id = address_person_address.address_id "+
+		"JOIN `person` AS `address_person_address` ON `address`.`id` = `address_person_address`.`address_id` "+
 		"WHERE `address`.`id` = ? AND `address_person_address`.`name` = ? "+
 		"GROUP BY `address`.`id`")
 }
@@ -1815,7 +1932,7 @@ func (s *querySuite) Test_GenerateSelectSQL_WhereAutoJoinReverseToParentHint(c *
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 2)
 	c.Assert(sql, Equals, "SELECT `address`.`id`, `address`.`line1`, `address`.`line2`, `address`.`country_id` FROM `address` AS `address` "+
-		"JOIN person AS address_person_optional_address ON address.id = address_person_optional_address.optional_address_id "+
+		"JOIN `person` AS `address_person_optional_address` ON `address`.`id` = `address_person_optional_address`.`optional_address_id` "+
 		"WHERE `address`.`id` = ? AND `address_person_optional_address`.`name` = ? "+
 		"GROUP BY `address`.`id`")
 }
@@ -1878,7 +1995,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoin(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 1)
 	c.Assert(sql, Equals, "SELECT COUNT(*) FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
 		"WHERE `person_optional_address`.`line1` = ?")
 }
 
@@ -1892,8 +2009,8 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinDeep(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 1)
 	c.Assert(sql, Equals, "SELECT COUNT(*) FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN country AS person_optional_address_country ON person_optional_address.country_id = person_optional_address_country.id "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `country` AS `person_optional_address_country` ON `person_optional_address`.`country_id` = `person_optional_address_country`.`id` "+
 		"WHERE `person_optional_address_country`.`id` = ?")
 }
 
@@ -1907,7 +2024,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinOrderBy(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 0)
 	c.Assert(sql, Equals, "SELECT COUNT(*) FROM `person` AS `person` "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id")
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id`")
 }
 
 //joining multiple tables (test no duplicate joins)
@@ -1926,10 +2043,10 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinComplex(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 7)
 	c.Assert(sql, Equals, "SELECT COUNT(*) FROM `person` AS `person` "+
-		"JOIN address AS person_address ON person.address_id = person_address.id "+
-		"JOIN country AS person_address_country ON person_address.country_id = person_address_country.id "+
-		"JOIN address AS person_optional_address ON person.optional_address_id = person_optional_address.id "+
-		"JOIN country AS person_optional_address_country ON person_optional_address.country_id = person_optional_address_country.id "+
+		"JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` "+
+		"JOIN `country` AS `person_address_country` ON `person_address`.`country_id` = `person_address_country`.`id` "+
+		"JOIN `address` AS `person_optional_address` ON `person`.`optional_address_id` = `person_optional_address`.`id` "+
+		"JOIN `country` AS `person_optional_address_country` ON `person_optional_address`.`country_id` = `person_optional_address_country`.`id` "+
 		"WHERE `person`.`id` = ? AND `person`.`name` = ? AND `person_address_country`.`id` = ? AND `person_optional_address`.`line1` = ? AND "+
 		"`person_optional_address_country`.`id` = ? AND `person_optional_address_country`.`id` = ? AND `person_address`.`line1` = ?")
 }
@@ -1945,7 +2062,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinMany(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 4)
 	c.Assert(sql, Equals, "SELECT COUNT(DISTINCT `person`.`id`) FROM `person` AS `person` "+
-		"JOIN telephone AS person_telephones ON person.id = person_telephones.person_id "+
+		"JOIN `telephone` AS `person_telephones` ON `person`.`id` = `person_telephones`.`person_id` "+
 		"WHERE `person_telephones`.`number` = ? AND `person_telephones`.`id` IN (?,?,?)")
 }
 
@@ -1961,7 +2078,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinReverseToParent(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 3)
 	c.Assert(sql, Equals, "SELECT COUNT(DISTINCT `country`.`id`) FROM `country` AS `country` "+
-		"JOIN address AS country_address_country ON country.id = country_address_country.country_id "+
+		"JOIN `address` AS `country_address_country` ON `country`.`id` = `country_address_country`.`country_id` "+
 		"WHERE `country`.`id` = ? AND `country`.`name` = ? AND `country_address_country`.`line1` = ?")
 }
 
@@ -1977,7 +2094,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinReverseToParentFirstOccu
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 2)
 	c.Assert(sql, Equals, "SELECT COUNT(DISTINCT `address`.`id`) FROM `address` AS `address` "+
-		"JOIN person AS address_person_address ON address.id = address_person_address.address_id "+
+		"JOIN `person` AS `address_person_address` ON `address`.`id` = `address_person_address`.`address_id` "+
 		"WHERE `address`.`id` = ? AND `address_person_address`.`name` = ?")
 }
 
@@ -1991,7 +2108,7 @@ func (s *querySuite) Test_GenerateCountSQL_WhereAutoJoinReverseToParentHint(c *C
 	c.Assert(err, IsNil)
 	c.Assert(bind, HasLen, 2)
 	c.Assert(sql, Equals, "SELECT COUNT(DISTINCT `address`.`id`) FROM `address` AS `address` "+
-		"JOIN person AS address_person_optional_address ON address.id = address_person_optional_address.optional_address_id "+
+		"JOIN `person` AS `address_person_optional_address` ON `address`.`id` = `address_person_optional_address`.`optional_address_id` "+
 		"WHERE `address`.`id` = ? AND `address_person_optional_address`.`name` = ?")
 }
 
@@ -2047,14 +2164,14 @@ func (s *querySuite) Test_FormatAndResolveStatement(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(statement, HasLen, 1)
 	c.Assert(statement[0], Equals, "`person`.`address_id` = `person_address`.`id`")
-	c.Assert(joins, Equals, " JOIN address AS person_address ON person.address_id = person_address.id")
+	c.Assert(joins, Equals, " JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id`")
 
 	//check brackets
 	statement, joins, err = s.db.Query().formatAndResolveStatement(personTbl, "(address_id) = (address.id)")
 	c.Assert(err, IsNil)
 	c.Assert(statement, HasLen, 1)
 	c.Assert(statement[0], Equals, "(`person`.`address_id`) = (`person_address`.`id`)")
-	c.Assert(joins, Equals, " JOIN address AS person_address ON person.address_id = person_address.id")
+	c.Assert(joins, Equals, " JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id`")
 
 	//check all in backets
 	statement, joins, err = s.db.Query().formatAndResolveStatement(personTbl, "(address.id IN person.optionalAddressId)")
@@ -2069,7 +2186,7 @@ func (s *querySuite) Test_FormatAndResolveStatement(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(statement, HasLen, 1)
 	c.Assert(statement[0], Equals, "MIN(`person_address`.`id`) > 'id' AND MAX( `person`.`id` ) IN (1234, 12.34, `person`.`id`)")
-	c.Assert(joins, Equals, " JOIN address AS person_address ON person.address_id = person_address.id")
+	c.Assert(joins, Equals, " JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id`")
 
 	//test multiple return, double join
 	statement, joins, err = s.db.Query().formatAndResolveStatement(personTbl, "address.id = 1", "address.country.id = ?")
@@ -2077,12 +2194,12 @@ func (s *querySuite) Test_FormatAndResolveStatement(c *C) {
 	c.Assert(statement, HasLen, 2)
 	c.Assert(statement[0], Equals, "`person_address`.`id` = 1")
 	c.Assert(statement[1], Equals, "`person_address_country`.`id` = ?")
-	c.Assert(joins, Equals, " JOIN address AS person_address ON person.address_id = person_address.id JOIN country AS person_address_country ON person_address.country_id = person_address_country.id")
+	c.Assert(joins, Equals, " JOIN `address` AS `person_address` ON `person`.`address_id` = `person_address`.`id` JOIN `country` AS `person_address_country` ON `person_address`.`country_id` = `person_address_country`.`id`")
 
 	//test join on multiple
 	statement, joins, err = s.db.Query().formatAndResolveStatement(personTbl, "telephones.number = '11223344'")
 	c.Assert(err, IsNil)
 	c.Assert(statement, HasLen, 1)
 	c.Assert(statement[0], Equals, "`person_telephones`.`number` = '11223344'")
-	c.Assert(joins, Equals, " JOIN telephone AS person_telephones ON person.id = person_telephones.person_id")
+	c.Assert(joins, Equals, " JOIN `telephone` AS `person_telephones` ON `person`.`id` = `person_telephones`.`person_id`")
 }
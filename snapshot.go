@@ -0,0 +1,153 @@
+package storm
+
+import "reflect"
+
+//Snapshot enables dirty tracking when embedded in a structure
+//The column values are remembered when the structure is loaded or saved, Save will only update the changed columns
+//Example:
+// type Customer struct {
+//	storm.Snapshot
+//	Id   int
+//	Name string
+// }
+type Snapshot struct {
+	values map[string]interface{}
+}
+
+//Change holds the loaded and current value of a column
+type Change struct {
+	Old interface{}
+	New interface{}
+}
+
+var snapshotType = reflect.TypeOf(Snapshot{})
+
+//findSnapshot returns the index of the embedded snapshot, nil when the structure is not tracked
+func findSnapshot(t reflect.Type) []int {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == snapshotType {
+			return f.Index
+		}
+	}
+	return nil
+}
+
+//snapshot returns the embedded snapshot of the structure, nil when the structure is not tracked
+func (t *table) snapshot(v reflect.Value) *Snapshot {
+	if t.snapshotIndex == nil {
+		return nil
+	}
+	return v.FieldByIndex(t.snapshotIndex).Addr().Interface().(*Snapshot)
+}
+
+//takeSnapshot remembers the current column values of the structure
+func (t *table) takeSnapshot(v reflect.Value) error {
+	s := t.snapshot(v)
+	if s == nil {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(t.columns))
+	for _, col := range t.columns {
+		value, err := col.snapshotValue(v)
+		if err != nil {
+			return err
+		}
+		values[col.columnName] = value
+	}
+	s.values = values
+	return nil
+}
+
+//updateSnapshot remembers the current values of the provided columns, only when a snapshot is already taken
+func (t *table) updateSnapshot(v reflect.Value, cols []*column) error {
+	s := t.snapshot(v)
+	if !s.isTaken() {
+		return nil
+	}
+
+	//copy, the snapshot can be shared with copies of the structure
+	values := make(map[string]interface{}, len(s.values))
+	for column, value := range s.values {
+		values[column] = value
+	}
+
+	for _, col := range cols {
+		value, err := col.snapshotValue(v)
+		if err != nil {
+			return err
+		}
+		values[col.columnName] = value
+	}
+	s.values = values
+	return nil
+}
+
+//takeSnapshots remembers the loaded values of the scanned structure and its joined dependent structures
+func takeSnapshots(v reflect.Value, tbl *table, scanned []reflect.Value, scanObjects []scanObject) error {
+	if err := tbl.takeSnapshot(v); err != nil {
+		return err
+	}
+	for i, scanObj := range scanObjects {
+		if err := scanObj.tbl.takeSnapshot(scanned[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//changes returns the columns who differ from the snapshot, all columns are changed when there is no snapshot taken yet
+func (t *table) changes(v reflect.Value) (map[string]Change, []*column, error) {
+	var (
+		s       = t.snapshot(v)
+		changes = make(map[string]Change)
+		cols    []*column
+	)
+
+	for _, col := range t.columns {
+		value, err := col.snapshotValue(v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var (
+			old interface{}
+			ok  bool
+		)
+		if s.isTaken() {
+			old, ok = s.values[col.columnName]
+		}
+		if !ok || !reflect.DeepEqual(old, value) {
+			changes[col.columnName] = Change{Old: old, New: value}
+			cols = append(cols, col)
+		}
+	}
+	return changes, cols, nil
+}
+
+//snapshotValue copies the column value, pointers are dereferenced and json columns are compared encoded
+func (col *column) snapshotValue(v reflect.Value) (interface{}, error) {
+	field := v.FieldByIndex(col.goIndex)
+	if col.isJSON {
+		return jsonValue{field}.Value()
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			return nil, nil
+		}
+		return field.Elem().Interface(), nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 && !field.IsNil() {
+			return append([]byte(nil), field.Bytes()...), nil
+		}
+	}
+	return field.Interface(), nil
+}
+
+//isTaken checks if the values are remembered
+func (s *Snapshot) isTaken() bool {
+	return s != nil && s.values != nil
+}
@@ -1,6 +1,11 @@
 package storm
 
-import "database/sql"
+import (
+	"bytes"
+	"database/sql"
+
+	"github.com/mbict/storm/dialect"
+)
 
 //common function who sql/tx and sdl/db share
 type sqlCommon interface {
@@ -9,3 +14,30 @@ type sqlCommon interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
+
+//rebind replaces the ? bind markers in the query with the bind variable notation of the dialect
+//markers inside quoted strings and identifiers are left untouched
+func rebind(d dialect.Dialect, query string) string {
+	var (
+		buf   bytes.Buffer
+		quote rune
+		pos   int
+	)
+
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			pos++
+			buf.WriteString(d.BindVar(pos))
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -3,18 +3,30 @@ package storm
 import (
 	"bytes"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mbict/storm/dialect"
 )
 
 var RecordNotFound = sql.ErrNoRows
 
+//ErrStaleObject is returned when a versioned record is changed or deleted since it was loaded
+type ErrStaleObject struct {
+	Table   string
+	Version int64
+}
+
+func (e *ErrStaleObject) Error() string {
+	return fmt.Sprintf("stale object, record in `%s` is changed or deleted since version %d", e.Table, e.Version)
+}
+
 //Context interface for Transaction and Query
 type Context interface {
 	DB() sqlCommon
@@ -28,14 +40,79 @@ type Context interface {
 	Offset(offset int) *Query
 	Find(i interface{}, where ...interface{}) error
 	Dependent(i interface{}, columns ...string) error
+	DependentRecursive(i interface{}, recursions ...Recursion) error
 	Delete(i interface{}) error
-	Save(i interface{}) error
+	HardDelete(i interface{}) error
+	Save(i interface{}, options ...SaveOption) error
+	Insert(i interface{}) error
+	InsertAll(i interface{}) error
+	Update(i interface{}) error
+	UpdateColumns(i interface{}, columns ...string) error
+	Changes(i interface{}) (map[string]Change, error)
+	Upsert(i interface{}, conflict *Conflict) error
 
 	table(t reflect.Type) (tbl *table, ok bool)
 	tableByName(s string) (tbl *table, ok bool)
 	logger() *log.Logger
 }
 
+//Conflict describes the unique columns a upsert conflicts on and the columns updated when it does
+type Conflict struct {
+	columns []string
+	update  []string
+}
+
+//OnConflict creates the conflict target for Upsert, without columns the primary keys are used
+//Columns can be provided by their column or field name
+func OnConflict(columns ...string) *Conflict {
+	return &Conflict{columns: columns}
+}
+
+//Update sets the columns which get the new values when the record already exists
+func (conflict *Conflict) Update(columns ...string) *Conflict {
+	conflict.update = append(conflict.update, columns...)
+	return conflict
+}
+
+//SaveOption changes the behaviour of Save
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	columnNames []string
+	columns     []*column
+	cascade     []string
+}
+
+//Columns restricts the update to the provided columns, columns can be provided by their column or field name
+//A new record is always inserted with all the columns
+func Columns(columns ...string) SaveOption {
+	return func(opts *saveOptions) {
+		opts.columnNames = append(opts.columnNames, columns...)
+	}
+}
+
+//Cascade saves the provided related structures in the same transaction, relations can be provided by their name or field name
+//Nested relations are separated by a dot, e.g. Cascade("Addresses.Country")
+func Cascade(relations ...string) SaveOption {
+	return func(opts *saveOptions) {
+		opts.cascade = append(opts.cascade, relations...)
+	}
+}
+
+//newSaveOptions applies the options and resolves the columns against the table
+func newSaveOptions(tbl *table, options []SaveOption) (*saveOptions, error) {
+	opts := &saveOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	var err error
+	if len(opts.columnNames) > 0 {
+		opts.columns, err = tbl.resolveColumns(opts.columnNames)
+	}
+	return opts, err
+}
+
 //Storm structure
 type Storm struct {
 	db        *sql.DB
@@ -43,19 +120,42 @@ type Storm struct {
 	tables    map[reflect.Type]*table
 	tableLock sync.RWMutex
 	log       *log.Logger
+	clock     func() time.Time
 }
 
 //DB is a alias for Storm
 type DB Storm
 
-//Open opens a new connection to the datastore
+//Open opens a new connection to the datastore, the dialect is looked up by the driver name
 func Open(driverName string, dataSourceName string) (*Storm, error) {
+	d := dialect.New(driverName)
+	if d == nil {
+		return nil, fmt.Errorf("no dialect registered for driver `%s`", driverName)
+	}
+	return OpenWithDialect(driverName, dataSourceName, d)
+}
+
+//OpenWithDialect opens a new connection to the datastore using the provided dialect
+func OpenWithDialect(driverName string, dataSourceName string, d dialect.Dialect) (*Storm, error) {
 	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDB(db, d)
+}
+
+//OpenDB creates a storm instance on a existing database connection using the provided dialect
+func OpenDB(db *sql.DB, d dialect.Dialect) (*Storm, error) {
+	if d == nil {
+		return nil, errors.New("no dialect provided")
+	}
+
 	return &Storm{
 		db:      db,
-		dialect: dialect.New(driverName),
+		dialect: d,
 		tables:  make(map[reflect.Type]*table),
-	}, err
+		clock:   time.Now,
+	}, nil
 }
 
 //SetMaxIdleConns will the the maxiumum of idle connections
@@ -98,6 +198,11 @@ func (storm *Storm) Log(log *log.Logger) {
 	storm.log = log
 }
 
+//Clock you can assign the function used for the created and updated timestamps
+func (storm *Storm) Clock(clock func() time.Time) {
+	storm.clock = clock
+}
+
 //Query Create a new query object
 func (storm *Storm) Query() *Query {
 	return newQuery(storm, nil)
@@ -137,10 +242,27 @@ func (storm *Storm) Dependent(i interface{}, columns ...string) error {
 	return storm.Query().Dependent(i, columns...)
 }
 
+//DependentRecursive loads the subtrees of the self referencing relations created with Recursive
+func (storm *Storm) DependentRecursive(i interface{}, recursions ...Recursion) error {
+	return storm.Query().DependentRecursive(i, recursions...)
+}
+
 //Delete will delete the provided structure from the datastore
+//Structures with a soft delete column are marked as deleted instead
 func (storm *Storm) Delete(i interface{}) error {
 	tx := storm.Begin()
-	err := storm.deleteEntity(i, tx)
+	err := storm.deleteEntity(i, tx, false)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//HardDelete will delete the provided structure from the datastore, also when it has a soft delete column
+func (storm *Storm) HardDelete(i interface{}) error {
+	tx := storm.Begin()
+	err := storm.deleteEntity(i, tx, true)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -149,9 +271,74 @@ func (storm *Storm) Delete(i interface{}) error {
 }
 
 //Save will insert or update the provided structure in the datastore
-func (storm *Storm) Save(i interface{}) error {
+//Example:
+// db.Save(&customer)
+// db.Save(&customer, storm.Columns("Lastname")) //only updates the lastname of a existing record
+func (storm *Storm) Save(i interface{}, options ...SaveOption) error {
+	tx := storm.Begin()
+	err := storm.saveEntity(i, tx, options...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//Insert will insert the provided structure in the datastore, also when the primary key is already set
+func (storm *Storm) Insert(i interface{}) error {
+	tx := storm.Begin()
+	err := storm.insert(i, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//InsertAll will insert all the structures of the provided slice in a single transaction
+//The rows are inserted with multi-row inserts, the auto increment ids are set on the structures
+//Example:
+// err := db.InsertAll(&[]Customer{{Name: "first"}, {Name: "second"}})
+func (storm *Storm) InsertAll(i interface{}) error {
+	tx := storm.Begin()
+	err := storm.insertAll(i, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//Update will update the provided structure in the datastore
+//RecordNotFound is returned when no record is affected
+func (storm *Storm) Update(i interface{}) error {
+	tx := storm.Begin()
+	err := storm.update(i, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//UpdateColumns will only update the provided columns (column or field names) of the structure in the datastore
+//RecordNotFound is returned when no record is affected
+func (storm *Storm) UpdateColumns(i interface{}, columns ...string) error {
+	tx := storm.Begin()
+	err := storm.update(i, tx, Columns(columns...))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//Upsert will insert the provided structure or update the existing record when it conflicts on the unique columns
+//Example:
+// db.Upsert(&customer, storm.OnConflict("email").Update("name", "updated_at"))
+func (storm *Storm) Upsert(i interface{}, conflict *Conflict) error {
 	tx := storm.Begin()
-	err := storm.saveEntity(i, tx)
+	err := storm.upsert(i, conflict, tx)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -159,6 +346,22 @@ func (storm *Storm) Save(i interface{}) error {
 	return tx.Commit()
 }
 
+//Changes returns the old and new values of the changed columns of a tracked structure (embedding storm.Snapshot)
+//All the columns are changed when the structure is not loaded or saved yet
+func (storm *Storm) Changes(i interface{}) (map[string]Change, error) {
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if tbl.snapshotIndex == nil {
+		return nil, fmt.Errorf("structure `%s` does not embed storm.Snapshot, changes are not tracked", tbl.goType)
+	}
+
+	changes, _, err := tbl.changes(v)
+	return changes, err
+}
+
 //Begin will start a new transaction connection
 func (storm *Storm) Begin() *Transaction {
 	return newTransaction(storm)
@@ -181,13 +384,31 @@ func (storm *Storm) CreateTable(i interface{}) error {
 		return fmt.Errorf("no registered structure for `%s` found", t)
 	}
 
-	sqlCreateTable := storm.generateCreateTableSQL(tbl)
-	if storm.log != nil {
-		storm.log.Println(sqlCreateTable)
+	sqlCreateTable, err := storm.generateCreateTableSQL(tbl)
+	if err != nil {
+		return err
 	}
+	statements := []string{sqlCreateTable}
 
-	_, err := storm.db.Exec(sqlCreateTable)
-	return err
+	//the join tables of many to many relations
+	for _, rel := range storm.ownedJoinTables(tbl) {
+		sqlCreateTable, err = storm.generateCreateJoinTableSQL(tbl, rel)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, sqlCreateTable)
+	}
+
+	for _, sqlCreateTable := range statements {
+		if storm.log != nil {
+			storm.log.Println(sqlCreateTable)
+		}
+
+		if _, err = storm.db.Exec(sqlCreateTable); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 //DropTable removes the table in the datastore
@@ -208,13 +429,48 @@ func (storm *Storm) DropTable(i interface{}) error {
 		return fmt.Errorf("no registered structure for `%s` found", t)
 	}
 
-	sqlDropTable := storm.generateDropTableSQL(tbl)
-	if storm.log != nil {
-		storm.log.Println(sqlDropTable)
+	statements := []string{storm.generateDropTableSQL(tbl)}
+	for _, rel := range storm.ownedJoinTables(tbl) {
+		statements = append(statements, fmt.Sprintf("DROP TABLE %s", storm.dialect.Quote(rel.joinTable)))
 	}
 
-	_, err := storm.db.Exec(sqlDropTable)
-	return err
+	for _, sqlDropTable := range statements {
+		if storm.log != nil {
+			storm.log.Println(sqlDropTable)
+		}
+
+		if _, err := storm.db.Exec(sqlDropTable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//ownedJoinTables returns the many to many relations whose join table is created and dropped with the table
+//When both structures declare the relation, the join table belongs to the table whose name sorts first
+func (storm *Storm) ownedJoinTables(tbl *table) []*relation {
+	var owned []*relation
+	for _, rel := range tbl.relations {
+		if rel.joinTable == "" {
+			continue
+		}
+
+		reversed := false
+		for _, relRel := range rel.relTable.relations {
+			if relRel.joinTable == rel.joinTable && rel.relTable.tableName < tbl.tableName {
+				reversed = true
+			}
+		}
+		for _, ownedRel := range owned {
+			if ownedRel.joinTable == rel.joinTable {
+				reversed = true
+			}
+		}
+		if !reversed {
+			owned = append(owned, rel)
+		}
+	}
+	return owned
 }
 
 //RegisterStructure will parse the provided structure and links it to a table in the datastore
@@ -236,8 +492,16 @@ func (storm *Storm) RegisterStructure(i interface{}) error {
 		return fmt.Errorf("duplicate structure, '%s' already exists", t)
 	}
 
-	storm.tables[t] = newTable(reflect.New(t))
-	storm.resolveRelations()
+	tbl, err := newTable(reflect.New(t))
+	if err != nil {
+		return err
+	}
+
+	storm.tables[t] = tbl
+	if err := storm.resolveRelations(); err != nil {
+		delete(storm.tables, t)
+		return err
+	}
 
 	return nil
 }
@@ -252,6 +516,38 @@ func (storm *Storm) resolveRelations() error {
 				continue
 			}
 
+			//many to many through the join table
+			if joinTable, ok := rel.settings["many2many"]; ok && rel.goType.Kind() == reflect.Slice {
+				if relTbl, ok := storm.tables[rel.goSingularType]; ok {
+					joinKey, joinRelKey := tbl.tableName+"_id", relTbl.tableName+"_id"
+					if name, ok := rel.settings["joinkey"]; ok {
+						joinKey = name
+					}
+					if name, ok := rel.settings["joinrelkey"]; ok {
+						joinRelKey = name
+					}
+
+					//a self referencing relation needs distinct names for both sides
+					if joinKey == joinRelKey {
+						return fmt.Errorf("many to many relation `%s` of table `%s` links both sides with column `%s`, use joinkey() and joinrelkey() to name the columns", rel.name, tbl.tableName, joinKey)
+					}
+
+					rel.relTable = relTbl
+					rel.joinTable = joinTable
+					rel.joinKey = joinKey
+					rel.joinRelKey = joinRelKey
+				}
+				continue
+			}
+
+			//explicit foreign key and referenced column
+			if rel.isTagged() {
+				if err := storm.resolveTaggedRelation(tbl, rel); err != nil {
+					return err
+				}
+				continue
+			}
+
 			//find related columns One To One
 			colName := rel.name + "_id"
 			for _, relCol := range tbl.columns {
@@ -272,40 +568,76 @@ func (storm *Storm) resolveRelations() error {
 					}
 				}
 			}
-
-			//Todo: many to many resolve
 		}
 	}
 	return nil
 }
 
-func (storm *Storm) deleteEntity(i interface{}, tx *Transaction) (err error) {
-	v := reflect.ValueOf(i)
-	if v.Kind() != reflect.Ptr {
-		return errors.New("provided input is not by reference")
+//resolveTaggedRelation resolves a relation tagged with `fk` and/or `references`
+//The foreign key column defaults to the naming convention and the referenced column to the primary key
+//Tagged relations are resolved once the related structure is registered
+func (storm *Storm) resolveTaggedRelation(tbl *table, rel *relation) error {
+	relTbl, ok := storm.tables[typeIndirect(rel.goSingularType)]
+	if !ok {
+		return nil
 	}
 
-	v = v.Elem()
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return errors.New("provided input is a nil pointer")
+	//one to many the related table holds the foreign key, one to one the table itself
+	fkTbl, refTbl := tbl, relTbl
+	fk, ok := rel.settings["fk"]
+	if !ok {
+		fk = rel.name + "_id"
+	}
+	if rel.goType.Kind() == reflect.Slice {
+		fkTbl, refTbl = relTbl, tbl
+		if !ok {
+			fk = tbl.tableName + "_id"
 		}
 	}
-	v = reflect.Indirect(v)
 
-	if v.Kind() != reflect.Struct || !v.CanSet() {
-		return errors.New("provided input is not a structure type")
+	fkColumn := fkTbl.findColumn(fk)
+	if fkColumn == nil {
+		return fmt.Errorf("cannot resolve foreign key `%s` of relation `%s` in table `%s`", fk, rel.name, tbl.tableName)
 	}
 
-	//find the table
-	tbl, ok := storm.table(v.Type())
-	if !ok {
-		return fmt.Errorf("no registered structure for `%s` found", v.Type())
+	var refColumn *column
+	if ref, ok := rel.settings["references"]; ok {
+		if refColumn = refTbl.findColumn(ref); refColumn == nil {
+			return fmt.Errorf("cannot resolve referenced column `%s` of relation `%s` in table `%s`", ref, rel.name, tbl.tableName)
+		}
+	} else if refTbl.primaryKey() == nil {
+		return fmt.Errorf("cannot reference table `%s` without a single primary key by relation `%s` in table `%s`", refTbl.tableName, rel.name, tbl.tableName)
 	}
 
-	sqlDelete, bind := storm.generateDeleteSQL(v, tbl)
-	if storm.log != nil {
-		storm.log.Printf("`%s` binding : %v", sqlDelete, bind)
+	if fkTbl == relTbl {
+		rel.relTable = relTbl
+	}
+	rel.relColumn = fkColumn
+	rel.refColumn = refColumn
+	return nil
+}
+
+func (storm *Storm) deleteEntity(i interface{}, tx *Transaction, hard bool) (err error) {
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return err
+	}
+	return storm.deleteValue(v, tbl, tx, hard)
+}
+
+//deleteValue deletes the structure, the delete rules of the relations are applied first
+func (storm *Storm) deleteValue(v reflect.Value, tbl *table, tx *Transaction, hard bool) (err error) {
+	if len(tbl.keys) == 0 {
+		return errors.New("no primary key defined, cannot delete")
+	}
+
+	rules, err := storm.deleteRules(tbl)
+	if err != nil {
+		return err
+	}
+
+	if err = storm.restrictDelete(v, tbl, tx, rules, hard); err != nil {
+		return err
 	}
 
 	err = tbl.callbacks.invoke(v.Addr(), "OnDelete", tx)
@@ -313,6 +645,28 @@ func (storm *Storm) deleteEntity(i interface{}, tx *Transaction) (err error) {
 		return err
 	}
 
+	if err = storm.cascadeDelete(v, tbl, tx, rules, hard); err != nil {
+		return err
+	}
+
+	//soft delete, mark the record as deleted
+	var (
+		sqlDelete string
+		bind      []interface{}
+	)
+	if tbl.deletedColumn != nil && !hard {
+		if err = setTime(v.FieldByIndex(tbl.deletedColumn.goIndex), storm.clock()); err != nil {
+			return err
+		}
+		sqlDelete, bind = storm.generateSoftDeleteSQL(v, tbl)
+	} else {
+		sqlDelete, bind = storm.generateDeleteSQL(v, tbl)
+	}
+
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlDelete, bind)
+	}
+
 	_, err = tx.DB().Exec(sqlDelete, bind...)
 	if err != nil {
 		return err
@@ -321,181 +675,649 @@ func (storm *Storm) deleteEntity(i interface{}, tx *Transaction) (err error) {
 	return tbl.callbacks.invoke(v.Addr(), "OnPostDelete", tx)
 }
 
-func (storm *Storm) saveEntity(i interface{}, tx *Transaction) (err error) {
-
+//entityValue returns the structure value and table of the provided input
+func (storm *Storm) entityValue(i interface{}) (reflect.Value, *table, error) {
 	v := reflect.ValueOf(i)
 	if v.Kind() != reflect.Ptr {
-		return errors.New("provided input is not by reference")
+		return v, nil, errors.New("provided input is not by reference")
 	}
 
 	v = v.Elem()
 	if v.Kind() == reflect.Ptr {
 		if v.IsNil() {
-			return errors.New("provided input is a nil pointer")
+			return v, nil, errors.New("provided input is a nil pointer")
 		}
 	}
 	v = reflect.Indirect(v)
 
 	if v.Kind() != reflect.Struct || !v.CanSet() {
-		return errors.New("provided input is not a structure type")
+		return v, nil, errors.New("provided input is not a structure type")
 	}
 
 	//find the table
 	tbl, ok := storm.table(v.Type())
 	if !ok {
-		return fmt.Errorf("no registered structure for `%s` found", v.Type())
+		return v, nil, fmt.Errorf("no registered structure for `%s` found", v.Type())
 	}
+	return v, tbl, nil
+}
 
-	var (
-		sqlQuery string
-		bind     []interface{}
-	)
+func (storm *Storm) saveEntity(i interface{}, tx *Transaction, options ...SaveOption) (err error) {
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return err
+	}
+
+	opts, err := newSaveOptions(tbl, options)
+	if err != nil {
+		return err
+	}
+	return storm.saveValue(v, tbl, tx, opts)
+}
 
+//saveValue inserts or updates the structure, related structures are saved before and after when cascaded
+func (storm *Storm) saveValue(v reflect.Value, tbl *table, tx *Transaction, opts *saveOptions) (err error) {
+	parents, children, err := storm.cascadedRelations(tbl, opts.cascade)
+	if err != nil {
+		return err
+	}
+
+	if err = storm.saveParents(v, tbl, tx, parents); err != nil {
+		return err
+	}
+
+	var insert bool
 	if tbl.aiColumn != nil {
-		insert := v.FieldByIndex(tbl.aiColumn.goIndex).Int() == 0
-		if insert == true {
-			//insert
-			err = tbl.callbacks.invoke(v.Addr(), "OnInsert", tx)
-			sqlQuery, bind = storm.generateInsertSQL(v, tbl)
-		} else {
-			err = tbl.callbacks.invoke(v.Addr(), "OnUpdate", tx)
-			sqlQuery, bind = storm.generateUpdateSQL(v, tbl)
+		insert = v.FieldByIndex(tbl.aiColumn.goIndex).Int() == 0
+	} else if tbl.genColumn != nil && isEmptyValue(v.FieldByIndex(tbl.genColumn.goIndex)) {
+		insert = true
+	} else if len(tbl.keys) > 0 {
+		//without a auto increment or empty generated key we check if the record already exists
+		if insert, err = storm.isNewEntity(v, tbl, tbl.keys, tx); err != nil {
+			return err
 		}
+	} else {
+		return errors.New("no primary key defined, cannot determine to insert or update")
+	}
 
-		//no errors on the before callbacks
-		if err != nil {
+	if insert == true {
+		err = storm.insertEntity(v, tbl, tx)
+	} else {
+		err = storm.updateEntity(v, tbl, tx, false, opts.columns)
+	}
+	if err != nil {
+		return err
+	}
+	return storm.saveChildren(v, tbl, tx, children)
+}
+
+func (storm *Storm) insertEntity(v reflect.Value, tbl *table, tx *Transaction) (err error) {
+	if err = storm.touch(v, tbl, true); err != nil {
+		return err
+	}
+
+	if err = tbl.callbacks.invoke(v.Addr(), "OnInsert", tx); err != nil {
+		return err
+	}
+
+	if err = storm.generateKey(v, tbl); err != nil {
+		return err
+	}
+
+	sqlQuery, bind := storm.generateInsertSQL(v, tbl)
+	stmt, err := tx.DB().Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	if tbl.aiColumn != nil && !storm.hasPresetAI(v, tbl) {
+		var id int64
+		id, err = storm.dialect.InsertAutoIncrement(stmt, bind...)
+		v.FieldByIndex(tbl.aiColumn.goIndex).SetInt(id)
+	} else {
+		err = storm.presetAI(v, tbl, tx, func() error {
+			_, err := stmt.Exec(bind...)
 			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tbl.takeSnapshot(v); err != nil {
+		return err
+	}
+	return tbl.callbacks.invoke(v.Addr(), "OnPostInsert", tx)
+}
+
+//updateEntity updates the (provided columns of the) record, when mustExist is set RecordNotFound is returned if no record is affected
+func (storm *Storm) updateEntity(v reflect.Value, tbl *table, tx *Transaction, mustExist bool, columns []*column) (err error) {
+	if len(tbl.keys) == 0 {
+		return errors.New("no primary key defined, cannot update")
+	}
+
+	if err = tbl.callbacks.invoke(v.Addr(), "OnUpdate", tx); err != nil {
+		return err
+	}
+
+	//tracked structures only update the changed columns, nothing is written when there are no changes
+	partial := len(columns) > 0
+	if !partial && tbl.snapshot(v).isTaken() {
+		if _, columns, err = tbl.changes(v); err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			if err = storm.verifyUnchanged(v, tbl, tx, mustExist); err != nil {
+				return err
+			}
+			return tbl.callbacks.invoke(v.Addr(), "OnPostUpdate", tx)
 		}
+	}
 
-		//prepare
-		stmt, err := tx.DB().Prepare(sqlQuery)
-		if err != nil {
+	if err = storm.touch(v, tbl, false); err != nil {
+		return err
+	}
+	if len(columns) > 0 && tbl.updatedColumn != nil && !containsColumn(columns, tbl.updatedColumn) {
+		columns = append(columns, tbl.updatedColumn)
+	}
+
+	sqlQuery, bind := storm.generateUpdateSQL(v, tbl, columns...)
+
+	//nothing to update, all the (provided) columns are part of the primary key
+	if sqlQuery == "" {
+		if err = storm.verifyUnchanged(v, tbl, tx, mustExist); err != nil {
 			return err
 		}
-		defer stmt.Close()
+		return tbl.callbacks.invoke(v.Addr(), "OnPostUpdate", tx)
+	}
 
-		if storm.log != nil {
-			storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	stmt, err := tx.DB().Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	res, err := stmt.Exec(bind...)
+	if err != nil {
+		return err
+	}
+
+	if mustExist || tbl.versionColumn != nil {
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
 		}
 
-		if insert == true {
-			var id int64
-			id, err = storm.dialect.InsertAutoIncrement(stmt, bind...)
-			v.FieldByIndex(tbl.aiColumn.goIndex).SetInt(id)
-			if err != nil {
+		if tbl.versionColumn != nil {
+			version := v.FieldByIndex(tbl.versionColumn.goIndex)
+			if affected == 0 {
+				return &ErrStaleObject{Table: tbl.tableName, Version: version.Int()}
+			}
+			version.SetInt(version.Int() + 1)
+		} else if affected == 0 {
+			//mysql only counts the changed rows, a update with the same values affects no rows
+			if err = storm.verifyUnchanged(v, tbl, tx, mustExist); err != nil {
 				return err
 			}
-			err = tbl.callbacks.invoke(v.Addr(), "OnPostInsert", tx)
-		} else {
-			_, err = stmt.Exec(bind...)
-			if err != nil {
+		}
+	}
+
+	//only the updated columns are remembered on a partial update
+	if partial {
+		err = tbl.updateSnapshot(v, columns)
+	} else {
+		err = tbl.takeSnapshot(v)
+	}
+	if err != nil {
+		return err
+	}
+	return tbl.callbacks.invoke(v.Addr(), "OnPostUpdate", tx)
+}
+
+//verifyUnchanged checks the record when there is nothing to update, like the update statement would
+//RecordNotFound is returned when the record must exist, ErrStaleObject when the version differs
+func (storm *Storm) verifyUnchanged(v reflect.Value, tbl *table, tx *Transaction, mustExist bool) error {
+	if !mustExist && tbl.versionColumn == nil {
+		return nil
+	}
+
+	cols := tbl.keys
+	if tbl.versionColumn != nil {
+		cols = append(append([]*column{}, tbl.keys...), tbl.versionColumn)
+	}
+
+	isNew, err := storm.isNewEntity(v, tbl, cols, tx)
+	if err != nil || !isNew {
+		return err
+	}
+
+	if tbl.versionColumn != nil {
+		return &ErrStaleObject{Table: tbl.tableName, Version: v.FieldByIndex(tbl.versionColumn.goIndex).Int()}
+	}
+	return RecordNotFound
+}
+
+func (storm *Storm) insert(i interface{}, tx *Transaction) error {
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return err
+	}
+	return storm.insertEntity(v, tbl, tx)
+}
+
+func (storm *Storm) update(i interface{}, tx *Transaction, options ...SaveOption) error {
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return err
+	}
+
+	opts, err := newSaveOptions(tbl, options)
+	if err != nil {
+		return err
+	}
+	return storm.updateEntity(v, tbl, tx, true, opts.columns)
+}
+
+func (storm *Storm) upsert(i interface{}, conflict *Conflict, tx *Transaction) error {
+	if !storm.dialect.Capabilities().Upsert {
+		return fmt.Errorf("dialect `%s` does not support upsert", storm.dialect.Name())
+	}
+
+	v, tbl, err := storm.entityValue(i)
+	if err != nil {
+		return err
+	}
+
+	if conflict == nil {
+		conflict = OnConflict()
+	}
+
+	//without conflict columns we conflict on the primary keys
+	conflictCols := tbl.keys
+	if len(conflict.columns) > 0 {
+		if conflictCols, err = tbl.resolveColumns(conflict.columns); err != nil {
+			return err
+		}
+	}
+	if len(conflictCols) == 0 {
+		return errors.New("no conflict columns defined for upsert")
+	}
+
+	//a generated id cannot conflict, the record is inserted and the id is read back
+	if tbl.aiColumn != nil && containsColumn(conflictCols, tbl.aiColumn) && !storm.hasPresetAI(v, tbl) {
+		return storm.insertEntity(v, tbl, tx)
+	}
+
+	updateCols, err := tbl.resolveColumns(conflict.update)
+	if err != nil {
+		return err
+	}
+
+	//the inserted values are used for the update, so both timestamps are set
+	if err = storm.touch(v, tbl, true); err != nil {
+		return err
+	}
+	if len(updateCols) > 0 && tbl.updatedColumn != nil && !containsColumn(updateCols, tbl.updatedColumn) {
+		updateCols = append(updateCols, tbl.updatedColumn)
+	}
+
+	//the existing record determines which callbacks are invoked
+	insert, err := storm.isNewEntity(v, tbl, conflictCols, tx)
+	if err != nil {
+		return err
+	}
+
+	if insert == true {
+		if err = tbl.callbacks.invoke(v.Addr(), "OnInsert", tx); err != nil {
+			return err
+		}
+		if err = storm.generateKey(v, tbl); err != nil {
+			return err
+		}
+	} else if err = tbl.callbacks.invoke(v.Addr(), "OnUpdate", tx); err != nil {
+		return err
+	}
+
+	sqlQuery, bind := storm.generateUpsertSQL(v, tbl, conflictCols, updateCols)
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	err = storm.presetAI(v, tbl, tx, func() error {
+		_, err := tx.DB().Exec(sqlQuery, bind...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	//refresh the auto increment id, the conflicting record keeps its own id
+	if tbl.aiColumn != nil {
+		condition, conditionBind := storm.generateCondition(v, conflictCols)
+		sqlQuery = rebind(storm.dialect, fmt.Sprintf("SELECT %s FROM %s WHERE %s", storm.dialect.Quote(tbl.aiColumn.columnName), storm.dialect.Quote(tbl.tableName), condition))
+		if storm.log != nil {
+			storm.log.Printf("`%s` binding : %v", sqlQuery, conditionBind)
+		}
+
+		var id int64
+		if err = tx.DB().QueryRow(sqlQuery, conditionBind...).Scan(&id); err != nil {
+			return err
+		}
+		v.FieldByIndex(tbl.aiColumn.goIndex).SetInt(id)
+	}
+
+	//the updated record can differ from the structure, so we only remember inserts
+	if insert == true {
+		if err = tbl.takeSnapshot(v); err != nil {
+			return err
+		}
+		return tbl.callbacks.invoke(v.Addr(), "OnPostInsert", tx)
+	}
+	return tbl.callbacks.invoke(v.Addr(), "OnPostUpdate", tx)
+}
+
+//touch sets the updated timestamp, and on insert the empty created timestamp
+func (storm *Storm) touch(v reflect.Value, tbl *table, insert bool) error {
+	now := storm.clock()
+	if insert && tbl.createdColumn != nil {
+		if field := v.FieldByIndex(tbl.createdColumn.goIndex); isEmptyValue(field) {
+			if err := setTime(field, now); err != nil {
 				return err
 			}
-			err = tbl.callbacks.invoke(v.Addr(), "OnPostUpdate", tx)
 		}
+	}
+
+	if tbl.updatedColumn != nil {
+		return setTime(v.FieldByIndex(tbl.updatedColumn.goIndex), now)
+	}
+	return nil
+}
+
+//setTime sets the time on a time or pointer to time field
+func setTime(field reflect.Value, now time.Time) error {
+	t := reflect.ValueOf(now)
+	if field.Kind() == reflect.Ptr && t.Type().ConvertibleTo(field.Type().Elem()) {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(t.Convert(field.Type().Elem()))
+		field.Set(ptr)
+		return nil
+	}
+
+	if !t.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("cannot set the time on a field of type `%s`", field.Type())
+	}
+	field.Set(t.Convert(field.Type()))
+	return nil
+}
+
+//generateKey fills the empty generated key column of the structure
+func (storm *Storm) generateKey(v reflect.Value, tbl *table) error {
+	if tbl.genColumn == nil {
+		return nil
+	}
+
+	field := v.FieldByIndex(tbl.genColumn.goIndex)
+	if !isEmptyValue(field) {
+		return nil
+	}
+
+	name := tbl.genColumn.settings["gen"]
+	generator, ok := keyGenerator(name)
+	if !ok {
+		return fmt.Errorf("unknown key generator `%s`", name)
+	}
+
+	key, err := generator()
+	if err != nil {
 		return err
 	}
-	return errors.New("no PK auto increment field defined dont know yet if to update or insert")
+	return assignKey(field, key)
 }
 
-func (storm *Storm) generateDeleteSQL(v reflect.Value, tbl *table) (string, []interface{}) {
+//isNewEntity checks if there is no record yet with the values of the columns (primary keys) of the structure
+func (storm *Storm) isNewEntity(v reflect.Value, tbl *table, cols []*column, tx *Transaction) (bool, error) {
+	condition, bind := storm.generateCondition(v, cols)
+	sqlQuery := rebind(storm.dialect, fmt.Sprintf("SELECT 1 FROM %s WHERE %s", storm.dialect.Quote(tbl.tableName), condition))
+	if storm.log != nil {
+		storm.log.Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	var exists int
+	err := tx.DB().QueryRow(sqlQuery, bind...).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return false, err
+}
+
+//generateCondition creates the where condition matching all the columns (primary keys) of the structure
+func (storm *Storm) generateCondition(v reflect.Value, cols []*column) (string, []interface{}) {
 	var (
-		sqlQuery bytes.Buffer
-		pos      int
-		bind     = make([]interface{}, 0)
+		condition bytes.Buffer
+		bind      = make([]interface{}, 0)
 	)
 
-	sqlQuery.WriteString(fmt.Sprintf("DELETE FROM `%s` WHERE ", tbl.tableName))
-	for _, col := range tbl.keys {
+	for pos, col := range cols {
 		if pos > 0 {
-			sqlQuery.WriteString(" AND ")
+			condition.WriteString(" AND ")
+		}
+		condition.WriteString(fmt.Sprintf("%s = ?", storm.dialect.Quote(col.columnName)))
+		bind = append(bind, col.bindValue(v))
+	}
+	return condition.String(), bind
+}
+
+func (storm *Storm) generateDeleteSQL(v reflect.Value, tbl *table) (string, []interface{}) {
+	condition, bind := storm.generateCondition(v, tbl.keys)
+	return rebind(storm.dialect, fmt.Sprintf("DELETE FROM %s WHERE %s", storm.dialect.Quote(tbl.tableName), condition)), bind
+}
+
+//generateSoftDeleteSQL sets the deleted timestamp of the record
+func (storm *Storm) generateSoftDeleteSQL(v reflect.Value, tbl *table) (string, []interface{}) {
+	condition, bind := storm.generateCondition(v, tbl.keys)
+	bind = append([]interface{}{tbl.deletedColumn.bindValue(v)}, bind...)
+	return rebind(storm.dialect, fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s", storm.dialect.Quote(tbl.tableName), storm.dialect.Quote(tbl.deletedColumn.columnName), condition)), bind
+}
+
+//presetAI executes the insert, explicit auto increment values are surrounded by the statements the dialect requires
+func (storm *Storm) presetAI(v reflect.Value, tbl *table, tx *Transaction, insert func() error) error {
+	if !storm.hasPresetAI(v, tbl) {
+		return insert()
+	}
+
+	before, after := storm.dialect.PresetAutoIncrementSQL(tbl.tableName, tbl.aiColumn.columnName)
+	if before != "" {
+		if storm.log != nil {
+			storm.log.Printf("`%s`", before)
 		}
-		sqlQuery.WriteString(fmt.Sprintf("`%s` = ?", col.columnName))
+		if _, err := tx.DB().Exec(before); err != nil {
+			return err
+		}
+	}
 
-		bind = append(bind, v.FieldByIndex(col.goIndex).Interface())
-		pos++
+	//the dialect statements are reverted also when the insert fails
+	err := insert()
+	if after != "" {
+		if storm.log != nil {
+			storm.log.Printf("`%s`", after)
+		}
+		if _, afterErr := tx.DB().Exec(after); err == nil {
+			err = afterErr
+		}
 	}
+	return err
+}
 
-	return sqlQuery.String(), bind
+//hasPresetAI checks if the auto increment column already has a value
+func (storm *Storm) hasPresetAI(v reflect.Value, tbl *table) bool {
+	return tbl.aiColumn != nil && v.FieldByIndex(tbl.aiColumn.goIndex).Int() != 0
 }
 
+//generateInsertSQL includes the auto increment column only when it has a preset value
 func (storm *Storm) generateInsertSQL(v reflect.Value, tbl *table) (string, []interface{}) {
+	sqlQuery, bind := storm.insertStatement(v, tbl, false)
+	return rebind(storm.dialect, sqlQuery), bind
+}
+
+//insertStatement creates the insert statement without rebinding, upserts never return the generated key
+func (storm *Storm) insertStatement(v reflect.Value, tbl *table, upsert bool) (string, []interface{}) {
 	var (
-		sqlColumns bytes.Buffer
-		sqlValues  bytes.Buffer
-		pos        int
-		bind       = make([]interface{}, 0)
+		columns   []string
+		sqlValues bytes.Buffer
+		bind      = make([]interface{}, 0)
+		aiColumn  string
 	)
 
+	presetAI := storm.hasPresetAI(v, tbl)
 	for _, col := range tbl.columns {
-		if col != tbl.aiColumn {
-			if pos > 0 {
-				sqlColumns.WriteString(", ")
+		if col != tbl.aiColumn || presetAI {
+			if len(columns) > 0 {
 				sqlValues.WriteString(", ")
 			}
 
-			sqlColumns.WriteString(fmt.Sprintf("`%s`", col.columnName))
+			columns = append(columns, col.columnName)
 			sqlValues.WriteString("?")
-			bind = append(bind, v.FieldByIndex(col.goIndex).Interface())
-			pos++
+			bind = append(bind, col.bindValue(v))
 		}
 	}
 
-	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", tbl.tableName, sqlColumns.String(), sqlValues.String()), bind
+	if tbl.aiColumn != nil && !presetAI && !upsert {
+		aiColumn = tbl.aiColumn.columnName
+	}
+
+	return storm.dialect.InsertSQL(tbl.tableName, columns, []string{"(" + sqlValues.String() + ")"}, aiColumn), bind
+}
+
+//generateUpsertSQL creates the insert with the conflict resolution of the dialect
+func (storm *Storm) generateUpsertSQL(v reflect.Value, tbl *table, conflict []*column, update []*column) (string, []interface{}) {
+	sqlInsert, bind := storm.insertStatement(v, tbl, true)
+
+	conflictColumns := make([]string, len(conflict))
+	for i, col := range conflict {
+		conflictColumns[i] = col.columnName
+	}
+
+	updateColumns := make([]string, len(update))
+	for i, col := range update {
+		updateColumns[i] = col.columnName
+	}
+
+	sqlQuery := storm.dialect.UpsertSQL(sqlInsert, conflictColumns, updateColumns)
+	return rebind(storm.dialect, sqlQuery), bind
 }
 
-func (storm *Storm) generateUpdateSQL(v reflect.Value, tbl *table) (string, []interface{}) {
+//generateUpdateSQL sets all or only the provided columns
+//A empty query is returned when there are no other columns than the primary keys
+func (storm *Storm) generateUpdateSQL(v reflect.Value, tbl *table, columns ...*column) (string, []interface{}) {
 	var (
 		sqlQuery bytes.Buffer
 		pos      int
 		bind     = make([]interface{}, 0)
 	)
 
-	sqlQuery.WriteString(fmt.Sprintf("UPDATE `%s` SET ", tbl.tableName))
+	sqlQuery.WriteString(fmt.Sprintf("UPDATE %s SET ", storm.dialect.Quote(tbl.tableName)))
 
 	for _, col := range tbl.columns {
-		if col != tbl.aiColumn {
+		if col != tbl.aiColumn && col != tbl.versionColumn && !tbl.isKey(col) && (len(columns) == 0 || containsColumn(columns, col)) {
 			if pos > 0 {
 				sqlQuery.WriteString(", ")
 			}
 
-			sqlQuery.WriteString(fmt.Sprintf("`%s` = ?", col.columnName))
-			bind = append(bind, v.FieldByIndex(col.goIndex).Interface())
+			sqlQuery.WriteString(fmt.Sprintf("%s = ?", storm.dialect.Quote(col.columnName)))
+			bind = append(bind, col.bindValue(v))
 			pos++
 		}
 	}
 
-	sqlQuery.WriteString(" WHERE ")
-	pos = 0
+	if pos == 0 {
+		return "", nil
+	}
+
+	//optimistic locking, the version is incremented and only matches when unchanged
+	if tbl.versionColumn != nil {
+		version := storm.dialect.Quote(tbl.versionColumn.columnName)
+		sqlQuery.WriteString(fmt.Sprintf(", %s = %s + 1", version, version))
+	}
 
 	if tbl.aiColumn != nil {
-		sqlQuery.WriteString(fmt.Sprintf("`%s` = ?", tbl.aiColumn.columnName))
-		bind = append(bind, v.FieldByIndex(tbl.aiColumn.goIndex).Interface())
+		sqlQuery.WriteString(fmt.Sprintf(" WHERE %s = ?", storm.dialect.Quote(tbl.aiColumn.columnName)))
+		bind = append(bind, tbl.aiColumn.bindValue(v))
 	} else {
-		for _, col := range tbl.keys {
-			if pos > 0 {
-				sqlQuery.WriteString(" AND ")
-			}
-			sqlQuery.WriteString(fmt.Sprintf("`%s` = ?", col.columnName))
-			bind = append(bind, v.FieldByIndex(col.goIndex).Interface())
-			pos++
-		}
+		condition, keyBind := storm.generateCondition(v, tbl.keys)
+		sqlQuery.WriteString(" WHERE " + condition)
+		bind = append(bind, keyBind...)
+	}
+
+	if tbl.versionColumn != nil {
+		sqlQuery.WriteString(fmt.Sprintf(" AND %s = ?", storm.dialect.Quote(tbl.versionColumn.columnName)))
+		bind = append(bind, tbl.versionColumn.bindValue(v))
 	}
-	return sqlQuery.String(), bind
+	return rebind(storm.dialect, sqlQuery.String()), bind
 }
 
-func (storm *Storm) generateCreateTableSQL(tbl *table) string {
+func (storm *Storm) generateCreateTableSQL(tbl *table) (string, error) {
 	var columns []string
 	for _, col := range tbl.columns {
 		column := reflect.Zero(col.goType).Interface()
+		if col.isJSON {
+			column = json.RawMessage(nil)
+		}
+
+		sqlType, err := storm.dialect.SqlType(column, col.size)
+		if err != nil {
+			return "", fmt.Errorf("cannot create column `%s`: %s", col.columnName, err)
+		}
+
 		params := ""
 		if tbl.aiColumn == col {
 			params = " " + storm.dialect.SqlPrimaryKey(column, 0)
 		}
-		columns = append(columns, storm.dialect.Quote(col.columnName)+" "+storm.dialect.SqlType(column, 0)+params)
+		columns = append(columns, storm.dialect.Quote(col.columnName)+" "+sqlType+params)
+	}
+
+	//primary keys without a auto increment column are added as table constraint
+	if tbl.aiColumn == nil && len(tbl.keys) > 0 {
+		keys := make([]string, len(tbl.keys))
+		for i, col := range tbl.keys {
+			keys[i] = storm.dialect.Quote(col.columnName)
+		}
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ",")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s)", storm.dialect.Quote(tbl.tableName), strings.Join(columns, ",")), nil
+}
+
+//generateCreateJoinTableSQL creates the join table of a many to many relation, the keys of both tables are the primary key
+func (storm *Storm) generateCreateJoinTableSQL(tbl *table, rel *relation) (string, error) {
+	key := tbl.primaryKey()
+	relKey := rel.relTable.primaryKey()
+	if key == nil || relKey == nil {
+		return "", fmt.Errorf("cannot link tables `%s` and `%s` without a single primary key", tbl.tableName, rel.relTable.tableName)
+	}
+
+	var columns []string
+	for _, link := range []struct {
+		name string
+		key  *column
+	}{{rel.joinKey, key}, {rel.joinRelKey, relKey}} {
+		sqlType, err := storm.dialect.SqlType(reflect.Zero(link.key.goType).Interface(), link.key.size)
+		if err != nil {
+			return "", fmt.Errorf("cannot create column `%s`: %s", link.name, err)
+		}
+		columns = append(columns, storm.dialect.Quote(link.name)+" "+sqlType)
 	}
+	columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s,%s)", storm.dialect.Quote(rel.joinKey), storm.dialect.Quote(rel.joinRelKey)))
 
-	return fmt.Sprintf("CREATE TABLE %s (%s)", storm.dialect.Quote(tbl.tableName), strings.Join(columns, ","))
+	return fmt.Sprintf("CREATE TABLE %s (%s)", storm.dialect.Quote(rel.joinTable), strings.Join(columns, ",")), nil
 }
 
 func (storm *Storm) generateDropTableSQL(tbl *table) string {
@@ -1,16 +1,38 @@
 package storm
 
 import (
+	"bytes"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"log"
 	"reflect"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/mattn/go-sqlite3"
+	"github.com/mbict/null"
+	"github.com/mbict/storm/dialect"
 	. "gopkg.in/check.v1"
 )
 
 type testErrorCallbackStruct struct{ Id int }
 
+type (
+	testUUID     [16]byte
+	testStatus   string
+	testGeometry interface {
+		Geometry() string
+	}
+	testPoint struct{ X, Y float64 }
+)
+
+func (testUUID) Value() (driver.Value, error)   { return "00000000-0000-0000-0000-000000000000", nil }
+func (testPoint) Value() (driver.Value, error)  { return "POINT(0 0)", nil }
+func (*testPoint) Scan(value interface{}) error { return nil }
+func (testPoint) Geometry() string              { return "POINT" }
+
 func (testErrorCallbackStruct) OnDelete() error {
 	return fmt.Errorf("delete callback error")
 }
@@ -61,6 +83,52 @@ func (s *stormSuite) TestClose(c *C) {
 	c.Assert(err, ErrorMatches, "sql: database is closed")
 }
 
+func (s *stormSuite) TestOpen_ErrorNoDialect(c *C) {
+	db, err := Open(`unknown_driver`, `:memory:`)
+	c.Assert(db, IsNil)
+	c.Assert(err, ErrorMatches, "no dialect registered for driver `unknown_driver`")
+}
+
+//registerWrappedDialect registers the driver and dialect once, registering twice panics
+var registerWrappedDialect sync.Once
+
+func (s *stormSuite) TestOpen_RegisteredDialect(c *C) {
+	registerWrappedDialect.Do(func() {
+		sql.Register("storm_wrapped_sqlite3", &sqlite3.SQLiteDriver{})
+		dialect.Register("storm_wrapped_sqlite3", func() dialect.Dialect { return dialect.New("sqlite3") })
+	})
+
+	db, err := Open(`storm_wrapped_sqlite3`, `:memory:`)
+	c.Assert(err, IsNil)
+	c.Assert(db.Dialect(), DeepEquals, dialect.New("sqlite3"))
+	c.Assert(db.Ping(), IsNil)
+	c.Assert(db.Close(), IsNil)
+}
+
+func (s *stormSuite) TestOpenWithDialect(c *C) {
+	db, err := OpenWithDialect(`sqlite3`, `:memory:`, dialect.New("postgres"))
+	c.Assert(err, IsNil)
+	c.Assert(db.Dialect(), DeepEquals, dialect.New("postgres"))
+	c.Assert(db.Close(), IsNil)
+
+	_, err = OpenWithDialect(`unknown_driver`, `:memory:`, dialect.New("sqlite3"))
+	c.Assert(err, ErrorMatches, `sql: unknown driver "unknown_driver".*`)
+}
+
+func (s *stormSuite) TestOpenDB(c *C) {
+	sqlDB, err := sql.Open(`sqlite3`, `:memory:`)
+	c.Assert(err, IsNil)
+
+	db, err := OpenDB(sqlDB, dialect.New("sqlite3"))
+	c.Assert(err, IsNil)
+	c.Assert(db.DB(), Equals, sqlDB)
+	c.Assert(db.Close(), IsNil)
+
+	db, err = OpenDB(sqlDB, nil)
+	c.Assert(db, IsNil)
+	c.Assert(err, ErrorMatches, "no dialect provided")
+}
+
 //not realy a usefull test, but now we know ping doesnt generate a error
 func (s *stormSuite) TestPing(c *C) {
 	c.Assert(s.db.Ping(), IsNil)
@@ -95,6 +163,17 @@ func (s *stormSuite) TestRegisterStructure_ErrorNotAStructure(c *C) {
 	c.Assert(s.db.RegisterStructure(string("test")), ErrorMatches, `provided input is not a structure type`)
 }
 
+func (s *stormSuite) TestRegisterStructure_ErrorInvalidTag(c *C) {
+	type testInvalidEmbed struct {
+		Id   int
+		Name string `db:"embed"`
+	}
+
+	c.Assert(s.db.RegisterStructure((*testInvalidEmbed)(nil)), ErrorMatches, "cannot embed field `Name`, only structs can be embedded")
+	_, ok := s.db.table(reflect.TypeOf(testInvalidEmbed{}))
+	c.Assert(ok, Equals, false)
+}
+
 func (s *stormSuite) TestRegisterStructure_ErrorDuplicateRegister(c *C) {
 	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
 	c.Assert(s.db.RegisterStructure((*Person)(nil)), ErrorMatches, `duplicate structure, 'storm.Person' already exists`)
@@ -265,6 +344,730 @@ func (s *stormSuite) TestSave_Update(c *C) {
 	c.Assert(input.Name, Equals, "updated 2nd")
 }
 
+func (s *stormSuite) TestInsert_PresetId(c *C) {
+	c.Assert(s.db.RegisterStructure((*testStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_structure` (`id` INTEGER PRIMARY KEY, `name` TEXT)")
+	c.Assert(err, IsNil)
+
+	input := &testStructure{Id: 10, Name: "imported"}
+	c.Assert(s.db.Insert(&input), IsNil)
+	c.Assert(input.Id, Equals, 10) //preset pk is kept
+	c.Assert(input.onInsertInvoked, Equals, true)
+	c.Assert(input.onPostInserteInvoked, Equals, true)
+	c.Assert(input.onUpdateInvoked, Equals, false)
+
+	input = &testStructure{Name: "generated"}
+	c.Assert(s.db.Insert(&input), IsNil)
+	c.Assert(input.Id, Equals, 11)
+
+	input = nil
+	c.Assert(s.db.Find(&input, 10), IsNil)
+	c.Assert(input.Name, Equals, "imported")
+
+	//duplicate key
+	c.Assert(s.db.Insert(&testStructure{Id: 10}), NotNil)
+}
+
+func (s *stormSuite) TestInsertAll(c *C) {
+	c.Assert(s.db.RegisterStructure((*testStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_structure` (`id` INTEGER PRIMARY KEY, `name` TEXT)")
+	c.Assert(err, IsNil)
+
+	//more rows than fit in a single statement
+	input := make([]testStructure, 1200)
+	for i := range input {
+		input[i].Name = "name " + strconv.Itoa(i)
+	}
+	c.Assert(s.db.InsertAll(&input), IsNil)
+	for i, elem := range input {
+		c.Assert(elem.Id, Equals, i+1)
+		c.Assert(elem.onInsertInvoked, Equals, true)
+		c.Assert(elem.onPostInserteInvoked, Equals, true)
+	}
+
+	cnt, err := s.db.Query().Count((*testStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(1200))
+
+	var compare *testStructure
+	c.Assert(s.db.Find(&compare, 1200), IsNil)
+	c.Assert(compare.Name, Equals, "name 1199")
+
+	//preset ids are kept
+	ptrs := []*testStructure{{Name: "generated"}, {Id: 2000, Name: "imported"}, {Name: "generated after"}}
+	c.Assert(s.db.InsertAll(&ptrs), IsNil)
+	c.Assert(ptrs[0].Id, Equals, 1201)
+	c.Assert(ptrs[1].Id, Equals, 2000)
+	c.Assert(ptrs[2].Id, Equals, 2001)
+
+	//all or nothing
+	c.Assert(s.db.InsertAll(&[]testStructure{{Id: 3000}, {Id: 1}}), NotNil)
+	c.Assert(s.db.Find(&compare, 3000), Equals, sql.ErrNoRows)
+
+	c.Assert(s.db.InsertAll(&[]testStructure{}), IsNil)
+	c.Assert(s.db.InsertAll(&[]*testStructure{nil}), ErrorMatches, "cannot insert nil element at index 0")
+	c.Assert(s.db.InsertAll(&testStructure{}), ErrorMatches, "provided input is not a slice")
+	c.Assert(s.db.InsertAll([]testStructure{}), ErrorMatches, "provided input is not by reference")
+}
+
+func (s *stormSuite) TestUpdate(c *C) {
+	c.Assert(s.db.RegisterStructure((*testStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_structure` (`id` INTEGER PRIMARY KEY, `name` TEXT)")
+	c.Assert(err, IsNil)
+	_, err = s.db.DB().Exec("INSERT INTO `test_structure` (`id`, `name`) VALUES (1, 'first')")
+	c.Assert(err, IsNil)
+
+	input := &testStructure{Id: 1, Name: "updated"}
+	c.Assert(s.db.Update(&input), IsNil)
+	c.Assert(input.onUpdateInvoked, Equals, true)
+	c.Assert(input.onPostUpdateInvoked, Equals, true)
+	c.Assert(input.onInsertInvoked, Equals, false)
+
+	input = nil
+	c.Assert(s.db.Find(&input, 1), IsNil)
+	c.Assert(input.Name, Equals, "updated")
+}
+
+func (s *stormSuite) TestUpdate_ErrorRecordNotFound(c *C) {
+	c.Assert(s.db.RegisterStructure((*testStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_structure` (`id` INTEGER PRIMARY KEY, `name` TEXT)")
+	c.Assert(err, IsNil)
+
+	input := &testStructure{Id: 1, Name: "updated"}
+	c.Assert(s.db.Update(&input), Equals, RecordNotFound)
+	c.Assert(input.onPostUpdateInvoked, Equals, false)
+
+	//key only tables
+	type testKeyOnly struct {
+		LeftId  int `db:"pk"`
+		RightId int `db:"pk"`
+	}
+	c.Assert(s.db.RegisterStructure((*testKeyOnly)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testKeyOnly)(nil)), IsNil)
+	c.Assert(s.db.Update(&testKeyOnly{LeftId: 1, RightId: 2}), Equals, RecordNotFound)
+	c.Assert(s.db.Insert(&testKeyOnly{LeftId: 1, RightId: 2}), IsNil)
+	c.Assert(s.db.Update(&testKeyOnly{LeftId: 1, RightId: 2}), IsNil)
+}
+
+func (s *stormSuite) TestUpdate_UnchangedRowsNotAffected(c *C) {
+	c.Assert(s.db.RegisterStructure((*testStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_structure` (`id` INTEGER PRIMARY KEY, `name` TEXT)")
+	c.Assert(err, IsNil)
+	_, err = s.db.DB().Exec("INSERT INTO `test_structure` (`id`, `name`) VALUES (1, 'first')")
+	c.Assert(err, IsNil)
+
+	//like mysql, rows updated with the same values are not counted as affected
+	_, err = s.db.DB().Exec("CREATE TRIGGER `test_structure_unchanged` BEFORE UPDATE ON `test_structure` WHEN NEW.`name` IS OLD.`name` BEGIN SELECT RAISE(IGNORE); END")
+	c.Assert(err, IsNil)
+
+	input := &testStructure{Id: 1, Name: "first"}
+	c.Assert(s.db.Update(&input), IsNil)
+	c.Assert(input.onPostUpdateInvoked, Equals, true)
+
+	input = &testStructure{Id: 2, Name: "first"}
+	c.Assert(s.db.Update(&input), Equals, RecordNotFound)
+}
+
+func (s *stormSuite) TestSave_Columns(c *C) {
+	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*Person)(nil)), IsNil)
+
+	//new records are inserted with all the columns
+	input := &Person{Name: "first", AddressId: 1}
+	c.Assert(s.db.Save(&input, Columns("Name")), IsNil)
+	c.Assert(input.Id, Equals, 1)
+
+	//concurrent writer updates the address
+	_, err := s.db.DB().Exec("UPDATE `person` SET `address_id` = 2 WHERE `id` = 1")
+	c.Assert(err, IsNil)
+
+	input.Name = "updated"
+	c.Assert(s.db.Save(&input, Columns("Name")), IsNil)
+	c.Assert(input.onUpdateInvoked, Equals, true)
+
+	var compare *Person
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+	c.Assert(compare.Name, Equals, "updated")
+	c.Assert(compare.AddressId, Equals, 2)
+}
+
+func (s *stormSuite) TestUpdateColumns(c *C) {
+	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*Person)(nil)), IsNil)
+	c.Assert(s.db.Save(&Person{Name: "first", AddressId: 1}), IsNil)
+
+	//field and column names are resolved
+	c.Assert(s.db.UpdateColumns(&Person{Id: 1, Name: "ignored", AddressId: 3, OptionalAddressId: sql.NullInt64{Int64: 4, Valid: true}}, "AddressId", "optional_address_id"), IsNil)
+
+	var compare *Person
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+	c.Assert(compare.Name, Equals, "first")
+	c.Assert(compare.AddressId, Equals, 3)
+	c.Assert(compare.OptionalAddressId.Int64, Equals, int64(4))
+
+	c.Assert(s.db.UpdateColumns(&Person{Id: 2}, "Name"), Equals, RecordNotFound)
+}
+
+func (s *stormSuite) TestSave_ErrorUnknownColumns(c *C) {
+	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
+	c.Assert(s.db.Save(&Person{Id: 1}, Columns("Lastname")), ErrorMatches, "unknown column `Lastname` in table `person`")
+	c.Assert(s.db.UpdateColumns(&Person{Id: 1}, "Name", "Telephones"), ErrorMatches, "unknown column `Telephones` in table `person`")
+}
+
+func (s *stormSuite) TestSave_DirtyTracking(c *C) {
+	var buf bytes.Buffer
+	s.db.Log(log.New(&buf, "", 0))
+	c.Assert(s.db.RegisterStructure((*testTrackedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTrackedStructure)(nil)), IsNil)
+
+	email := "first@example.com"
+	input := &testTrackedStructure{Name: "first", Email: &email, Tags: []string{"a"}}
+	c.Assert(s.db.Save(&input), IsNil)
+
+	//concurrent writer
+	_, err := s.db.DB().Exec("UPDATE `test_tracked_structure` SET `email` = 'other@example.com'")
+	c.Assert(err, IsNil)
+
+	//no changes, no update
+	buf.Reset()
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(buf.String(), Equals, "")
+
+	//only changed columns are updated
+	input.Name = "updated"
+	input.Tags = append(input.Tags, "b")
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(buf.String(), Matches, "`UPDATE `test_tracked_structure` SET `name` = \\?, `tags` = \\? WHERE `id` = \\?` .*\n")
+
+	var compare *testTrackedStructure
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare.Name, Equals, "updated")
+	c.Assert(compare.Tags, DeepEquals, []string{"a", "b"})
+	c.Assert(*compare.Email, Equals, "other@example.com")
+
+	//loaded values are remembered
+	buf.Reset()
+	c.Assert(s.db.Save(&compare), IsNil)
+	c.Assert(buf.String(), Equals, "")
+}
+
+func (s *stormSuite) TestUpdate_DirtyTrackingUnchanged(c *C) {
+	c.Assert(s.db.RegisterStructure((*testTrackedStructure)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testTrackedVersionedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTrackedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTrackedVersionedStructure)(nil)), IsNil)
+
+	//deleted records are not found, also without changes
+	input := &testTrackedStructure{Name: "first"}
+	c.Assert(s.db.Save(&input), IsNil)
+	_, err := s.db.DB().Exec("DELETE FROM `test_tracked_structure`")
+	c.Assert(err, IsNil)
+	c.Assert(s.db.Update(&input), Equals, RecordNotFound)
+
+	//the version is checked, also without changes
+	versioned := &testTrackedVersionedStructure{Name: "first"}
+	c.Assert(s.db.Save(&versioned), IsNil)
+	c.Assert(s.db.Save(&versioned), IsNil)
+
+	_, err = s.db.DB().Exec("UPDATE `test_tracked_versioned_structure` SET `version` = `version` + 1")
+	c.Assert(err, IsNil)
+	c.Assert(s.db.Save(&versioned), DeepEquals, &ErrStaleObject{Table: "test_tracked_versioned_structure", Version: 0})
+}
+
+func (s *stormSuite) TestChanges(c *C) {
+	c.Assert(s.db.RegisterStructure((*testTrackedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTrackedStructure)(nil)), IsNil)
+	c.Assert(s.db.Save(&testTrackedStructure{Name: "first"}), IsNil)
+
+	var compare *testTrackedStructure
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+
+	changes, err := s.db.Changes(&compare)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 0)
+
+	email := "test@example.com"
+	compare.Name = "updated"
+	compare.Email = &email
+	changes, err = s.db.Changes(&compare)
+	c.Assert(err, IsNil)
+	c.Assert(changes, DeepEquals, map[string]Change{
+		"name":  {Old: "first", New: "updated"},
+		"email": {Old: nil, New: "test@example.com"},
+	})
+
+	//not loaded, all columns are changed
+	changes, err = s.db.Changes(&testTrackedStructure{Id: 2})
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 4)
+	c.Assert(changes["id"], DeepEquals, Change{Old: nil, New: 2})
+}
+
+func (s *stormSuite) TestChanges_ErrorNotTracked(c *C) {
+	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
+	_, err := s.db.Changes(&Person{})
+	c.Assert(err, ErrorMatches, "structure `storm.Person` does not embed storm.Snapshot, changes are not tracked")
+}
+
+func (s *stormSuite) TestSave_Version(c *C) {
+	c.Assert(s.db.RegisterStructure((*testVersionedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testVersionedStructure)(nil)), IsNil)
+
+	input := &testVersionedStructure{Name: "first"}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Version, Equals, 0)
+
+	var stale *testVersionedStructure
+	c.Assert(s.db.Find(&stale, input.Id), IsNil)
+
+	input.Name = "updated"
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Version, Equals, 1)
+
+	//lost update is detected
+	stale.Name = "lost update"
+	err := s.db.Save(&stale)
+	c.Assert(err, FitsTypeOf, &ErrStaleObject{})
+	c.Assert(err, ErrorMatches, "stale object, record in `test_versioned_structure` is changed or deleted since version 0")
+	c.Assert(stale.Version, Equals, 0)
+
+	var compare *testVersionedStructure
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare, DeepEquals, input)
+
+	//deleted record
+	c.Assert(s.db.Delete(&compare), IsNil)
+	c.Assert(s.db.Update(&input), DeepEquals, &ErrStaleObject{Table: "test_versioned_structure", Version: 1})
+}
+
+func (s *stormSuite) TestSave_Timestamps(c *C) {
+	c.Assert(s.db.RegisterStructure((*testTimestampStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTimestampStructure)(nil)), IsNil)
+
+	inserted := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := inserted.Add(time.Hour)
+	s.db.Clock(func() time.Time { return inserted })
+
+	input := &testTimestampStructure{Name: "first"}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.CreatedAt, Equals, inserted)
+	c.Assert(input.UpdatedAt, Equals, inserted)
+
+	s.db.Clock(func() time.Time { return updated })
+	input.Name = "updated"
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.CreatedAt, Equals, inserted)
+	c.Assert(input.UpdatedAt, Equals, updated)
+
+	var compare *testTimestampStructure
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare.CreatedAt.Equal(inserted), Equals, true)
+	c.Assert(compare.UpdatedAt.Equal(updated), Equals, true)
+
+	//partial updates include the updated timestamp
+	s.db.Clock(func() time.Time { return updated.Add(time.Hour) })
+	c.Assert(s.db.UpdateColumns(&input, "Name"), IsNil)
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare.UpdatedAt.Equal(updated.Add(time.Hour)), Equals, true)
+
+	//preset created timestamps are kept
+	imported := &testTimestampStructure{Name: "imported", CreatedAt: inserted.AddDate(-1, 0, 0)}
+	c.Assert(s.db.Insert(&imported), IsNil)
+	c.Assert(imported.CreatedAt, Equals, inserted.AddDate(-1, 0, 0))
+}
+
+func (s *stormSuite) TestSave_TaggedTimestamps(c *C) {
+	c.Assert(s.db.RegisterStructure((*testTaggedTimestampStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTaggedTimestampStructure)(nil)), IsNil)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.db.Clock(func() time.Time { return now })
+
+	input := &testTaggedTimestampStructure{}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Added, Equals, now)
+	c.Assert(input.Changed, Equals, now)
+}
+
+func (s *stormSuite) TestDelete_SoftDelete(c *C) {
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteStructure)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteNote)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testSoftDeleteStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testSoftDeleteNote)(nil)), IsNil)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.db.Clock(func() time.Time { return now })
+
+	input := &testSoftDeleteStructure{Name: "first"}
+	c.Assert(s.db.Save(&input), IsNil)
+	other := &testSoftDeleteStructure{Name: "second"}
+	c.Assert(s.db.Save(&other), IsNil)
+
+	note := &testSoftDeleteNote{TestSoftDeleteStructureId: other.Id, Text: "deleted"}
+	c.Assert(s.db.Save(&note), IsNil)
+	c.Assert(s.db.Save(&testSoftDeleteNote{TestSoftDeleteStructureId: other.Id, Text: "kept"}), IsNil)
+
+	//the record is marked as deleted
+	c.Assert(s.db.Delete(&input), IsNil)
+	c.Assert(input.DeletedAt, NotNil)
+	c.Assert(*input.DeletedAt, Equals, now)
+
+	var compare *testSoftDeleteStructure
+	c.Assert(s.db.Find(&compare, input.Id), Equals, sql.ErrNoRows)
+	cnt, err := s.db.Query().Count((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(1))
+
+	c.Assert(s.db.Query().Unscoped().Find(&compare, input.Id), IsNil)
+	c.Assert(compare.DeletedAt.Equal(now), Equals, true)
+	cnt, err = s.db.Query().Unscoped().Count((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(2))
+
+	//soft deleted related records are excluded from joins and dependent fetches
+	c.Assert(s.db.Delete(&note), IsNil)
+	c.Assert(s.db.Where("notes.text = ?", "deleted").First(&compare), Equals, sql.ErrNoRows)
+	c.Assert(s.db.Query().Unscoped().Where("notes.text = ?", "deleted").First(&compare), IsNil)
+	c.Assert(compare.Id, Equals, other.Id)
+
+	c.Assert(s.db.Dependent(&compare, "Notes"), IsNil)
+	c.Assert(compare.Notes, HasLen, 1)
+	c.Assert(compare.Notes[0].Text, Equals, "kept")
+	c.Assert(s.db.Query().Unscoped().Dependent(&compare, "Notes"), IsNil)
+	c.Assert(compare.Notes, HasLen, 2)
+
+	//hard delete removes the record
+	c.Assert(s.db.HardDelete(&input), IsNil)
+	c.Assert(s.db.Query().Unscoped().Find(&compare, input.Id), Equals, sql.ErrNoRows)
+}
+
+func (s *stormSuite) TestDelete_SoftDeleteDependentColumns(c *C) {
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteStructure)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteOwned)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testSoftDeleteStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testSoftDeleteOwned)(nil)), IsNil)
+
+	deleted := &testSoftDeleteStructure{Name: "deleted"}
+	c.Assert(s.db.Save(&deleted), IsNil)
+	kept := &testSoftDeleteStructure{Name: "kept"}
+	c.Assert(s.db.Save(&kept), IsNil)
+	c.Assert(s.db.Save(&testSoftDeleteOwned{Name: "first", OwnerId: deleted.Id}), IsNil)
+	c.Assert(s.db.Save(&testSoftDeleteOwned{Name: "second", OwnerId: kept.Id}), IsNil)
+	c.Assert(s.db.Delete(&deleted), IsNil)
+
+	//the owning records are kept, the soft deleted relation is left empty
+	var owned []*testSoftDeleteOwned
+	c.Assert(s.db.Query().DependentColumns("Owner").Order("id", ASC).Find(&owned), IsNil)
+	c.Assert(owned, HasLen, 2)
+	c.Assert(owned[0].Owner, DeepEquals, testSoftDeleteStructure{})
+	c.Assert(owned[1].Owner.Name, Equals, "kept")
+
+	c.Assert(s.db.Query().Unscoped().DependentColumns("Owner").Order("id", ASC).Find(&owned), IsNil)
+	c.Assert(owned, HasLen, 2)
+	c.Assert(owned[0].Owner.Name, Equals, "deleted")
+}
+
+func (s *stormSuite) TestDelete_SoftDeleteSetBased(c *C) {
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteStructure)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testSoftDeleteNote)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testSoftDeleteStructure)(nil)), IsNil)
+
+	c.Assert(s.db.InsertAll(&[]testSoftDeleteStructure{{Name: "first"}, {Name: "second"}, {Name: "third"}}), IsNil)
+
+	//matching records are marked as deleted
+	n, err := s.db.Where("name <> ?", "third").Delete((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(2))
+
+	cnt, err := s.db.Query().Count((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(1))
+
+	//already deleted records are not matched again
+	n, err = s.db.Query().Delete((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(1))
+
+	n, err = s.db.Query().HardDelete((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(0))
+
+	n, err = s.db.Query().Unscoped().HardDelete((*testSoftDeleteStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(3))
+}
+
+func (s *stormSuite) TestSave_Cascade(c *C) {
+	for _, i := range []interface{}{(*testCascadeCustomer)(nil), (*testCascadeTelephone)(nil), (*testCascadeAddress)(nil), (*testCascadeCountry)(nil)} {
+		c.Assert(s.db.RegisterStructure(i), IsNil)
+		c.Assert(s.db.CreateTable(i), IsNil)
+	}
+
+	input := &testCascadeCustomer{
+		Name:      "customer",
+		Telephone: &testCascadeTelephone{Number: "111-11-1111"},
+		Addresses: []testCascadeAddress{
+			{Line: "first", Country: &testCascadeCountry{Name: "nl"}},
+			{Line: "second"},
+		},
+	}
+	c.Assert(s.db.Save(&input, Cascade("Telephone", "Addresses")), IsNil)
+	c.Assert(input.Id, Equals, 1)
+	c.Assert(input.Telephone.Id, Equals, 1)
+	c.Assert(input.TelephoneId, Equals, 1)
+	c.Assert(input.Addresses[0].Id, Equals, 1)
+	c.Assert(input.Addresses[0].TestCascadeCustomerId, Equals, 1)
+	c.Assert(input.Addresses[1].TestCascadeCustomerId, Equals, 1)
+
+	//tagged relations are saved without the option
+	c.Assert(input.Addresses[0].Country.Id, Equals, 1)
+	c.Assert(input.Addresses[0].CountryId, Equals, sql.NullInt64{Int64: 1, Valid: true})
+	c.Assert(input.Addresses[1].CountryId.Valid, Equals, false)
+
+	var compare *testCascadeCustomer
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare.TelephoneId, Equals, 1)
+	c.Assert(s.db.Dependent(&compare, "Telephone", "Addresses"), IsNil)
+	c.Assert(compare.Telephone.Number, Equals, "111-11-1111")
+	c.Assert(compare.Addresses, HasLen, 2)
+
+	//existing relations are updated, new ones inserted
+	input.Addresses[1].Line = "updated"
+	input.Addresses = append(input.Addresses, testCascadeAddress{Line: "third"})
+	c.Assert(s.db.Save(&input, Cascade("Addresses")), IsNil)
+	c.Assert(input.Addresses[2].Id, Equals, 3)
+	c.Assert(s.db.Dependent(&compare, "Addresses"), IsNil)
+	c.Assert(compare.Addresses, HasLen, 3)
+	c.Assert(compare.Addresses[1].Line, Equals, "updated")
+
+	//only the root is saved without cascade
+	input.Telephone.Number = "222-22-2222"
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(s.db.Dependent(&compare, "Telephone"), IsNil)
+	c.Assert(compare.Telephone.Number, Equals, "111-11-1111")
+
+	c.Assert(s.db.Save(&input, Cascade("Unknown")), ErrorMatches, "unknown relation `Unknown` in table `test_cascade_customer`")
+}
+
+func (s *stormSuite) TestDelete_Rules(c *C) {
+	for _, i := range []interface{}{(*testDeleteCustomer)(nil), (*testDeleteAddress)(nil), (*testDeleteLine)(nil), (*testDeleteOrder)(nil), (*testDeleteNote)(nil)} {
+		c.Assert(s.db.RegisterStructure(i), IsNil)
+		c.Assert(s.db.CreateTable(i), IsNil)
+	}
+
+	input := &testDeleteCustomer{
+		Name: "customer",
+		Addresses: []testDeleteAddress{
+			{Line: "first", Lines: []testDeleteLine{{Text: "a"}, {Text: "b"}}},
+			{Line: "second", Lines: []testDeleteLine{{Text: "c"}}},
+		},
+		Notes: []testDeleteNote{{Text: "note"}},
+	}
+	c.Assert(s.db.Save(&input, Cascade("Addresses.Lines", "Notes")), IsNil)
+	c.Assert(s.db.Save(&testDeleteOrder{TestDeleteCustomerId: input.Id}), IsNil)
+	other := &testDeleteAddress{Line: "other", Lines: []testDeleteLine{{Text: "other"}}}
+	c.Assert(s.db.Save(&other, Cascade("Lines")), IsNil)
+
+	//restricted by the order
+	c.Assert(s.db.Delete(&input), ErrorMatches, "cannot delete record of `test_delete_customer`, relation `orders` still has 1 related records")
+	cnt, err := s.db.Query().Count((*testDeleteAddress)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(3))
+
+	_, err = s.db.Query().Delete((*testDeleteOrder)(nil))
+	c.Assert(err, IsNil)
+
+	//cascaded recursive with callbacks, notes are kept without reference
+	testDeleteCallbacks = nil
+	c.Assert(s.db.Delete(&input), IsNil)
+	c.Assert(testDeleteCallbacks, DeepEquals, []string{"OnDelete a", "OnPostDelete a", "OnDelete b", "OnPostDelete b", "OnDelete c", "OnPostDelete c"})
+
+	cnt, err = s.db.Query().Count((*testDeleteAddress)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(1))
+	cnt, err = s.db.Query().Count((*testDeleteLine)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(1))
+
+	var note *testDeleteNote
+	c.Assert(s.db.Find(&note, input.Notes[0].Id), IsNil)
+	c.Assert(note.TestDeleteCustomerId.Valid, Equals, false)
+}
+
+func (s *stormSuite) TestDelete_RulesSoftDelete(c *C) {
+	for _, i := range []interface{}{(*testSoftDeleteParent)(nil), (*testSoftDeleteChild)(nil), (*testSoftDeleteLink)(nil)} {
+		c.Assert(s.db.RegisterStructure(i), IsNil)
+		c.Assert(s.db.CreateTable(i), IsNil)
+	}
+
+	input := &testSoftDeleteParent{}
+	c.Assert(s.db.Save(&input), IsNil)
+	child := &testSoftDeleteChild{TestSoftDeleteParentId: input.Id}
+	c.Assert(s.db.Save(&child), IsNil)
+	link := &testSoftDeleteLink{TestSoftDeleteParentId: sql.NullInt64{Int64: int64(input.Id), Valid: true}}
+	c.Assert(s.db.Save(&link), IsNil)
+	c.Assert(s.db.Delete(&child), IsNil)
+
+	//a soft delete can be restored, the link keeps its reference
+	c.Assert(s.db.Delete(&input), IsNil)
+	c.Assert(s.db.Find(&link, link.Id), IsNil)
+	c.Assert(link.TestSoftDeleteParentId.Valid, Equals, true)
+
+	//the soft deleted child still references the record
+	c.Assert(s.db.HardDelete(&input), ErrorMatches, "cannot delete record of `test_soft_delete_parent`, relation `children` still has 1 related records")
+
+	c.Assert(s.db.HardDelete(&child), IsNil)
+	c.Assert(s.db.HardDelete(&input), IsNil)
+	c.Assert(s.db.Find(&link, link.Id), IsNil)
+	c.Assert(link.TestSoftDeleteParentId.Valid, Equals, false)
+}
+
+func (s *stormSuite) TestManyToMany(c *C) {
+	c.Assert(s.db.RegisterStructure((*testTaggedCustomer)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testTag)(nil)), IsNil)
+
+	//the join table is created once, with the table sorting first
+	c.Assert(s.db.CreateTable((*testTaggedCustomer)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTag)(nil)), IsNil)
+
+	c.Assert(s.db.InsertAll(&[]testTaggedCustomer{{Name: "first"}, {Name: "second"}, {Name: "third"}}), IsNil)
+	c.Assert(s.db.InsertAll(&[]testTag{{Name: "vip"}, {Name: "new"}}), IsNil)
+	_, err := s.db.DB().Exec("INSERT INTO `test_customer_tag` (`test_tagged_customer_id`, `test_tag_id`) VALUES (1, 1), (1, 2), (2, 2)")
+	c.Assert(err, IsNil)
+
+	//dependent loading
+	var customer *testTaggedCustomer
+	c.Assert(s.db.Find(&customer, 1), IsNil)
+	c.Assert(s.db.Dependent(&customer, "Tags"), IsNil)
+	c.Assert(customer.Tags, HasLen, 2)
+	c.Assert(customer.Tags[0].Name, Equals, "vip")
+	c.Assert(customer.Tags[1].Name, Equals, "new")
+
+	c.Assert(s.db.Query().DependentColumns("Tags").Where("id = ?", 2).First(&customer), IsNil)
+	c.Assert(customer.Tags, HasLen, 1)
+	c.Assert(customer.Tags[0].Name, Equals, "new")
+
+	c.Assert(s.db.Query().DependentColumns("Tags").Where("id = ?", 3).First(&customer), IsNil)
+	c.Assert(customer.Tags, HasLen, 0)
+
+	var tag *testTag
+	c.Assert(s.db.Find(&tag, 2), IsNil)
+	c.Assert(s.db.Dependent(&tag, "Customers"), IsNil)
+	c.Assert(tag.Customers, HasLen, 2)
+
+	//auto join in conditions
+	var customers []*testTaggedCustomer
+	c.Assert(s.db.Where("tags.name = ?", "new").Order("id", ASC).Find(&customers), IsNil)
+	c.Assert(customers, HasLen, 2)
+	c.Assert(customers[0].Id, Equals, 1)
+	c.Assert(customers[1].Id, Equals, 2)
+
+	cnt, err := s.db.Where("tags.name IN (?, ?)", "vip", "new").Count((*testTaggedCustomer)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(2))
+
+	c.Assert(s.db.DropTable((*testTag)(nil)), IsNil)
+	_, err = s.db.DB().Exec("SELECT * FROM `test_customer_tag`")
+	c.Assert(err, NotNil)
+}
+
+func (s *stormSuite) TestManyToMany_SelfReferencing(c *C) {
+	c.Assert(s.db.RegisterStructure((*testFriend)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testFriend)(nil)), IsNil)
+
+	c.Assert(s.db.InsertAll(&[]testFriend{{Name: "first"}, {Name: "second"}, {Name: "third"}}), IsNil)
+	_, err := s.db.DB().Exec("INSERT INTO `test_friendship` (`friend_id`, `befriended_id`) VALUES (1, 2), (1, 3), (3, 2)")
+	c.Assert(err, IsNil)
+
+	var friend *testFriend
+	c.Assert(s.db.Find(&friend, 1), IsNil)
+	c.Assert(s.db.Dependent(&friend, "Friends", "FriendsOf"), IsNil)
+	c.Assert(friend.Friends, HasLen, 2)
+	c.Assert(friend.Friends[0].Name, Equals, "second")
+	c.Assert(friend.Friends[1].Name, Equals, "third")
+	c.Assert(friend.FriendsOf, HasLen, 0)
+
+	c.Assert(s.db.Find(&friend, 2), IsNil)
+	c.Assert(s.db.Dependent(&friend, "Friends", "FriendsOf"), IsNil)
+	c.Assert(friend.Friends, HasLen, 0)
+	c.Assert(friend.FriendsOf, HasLen, 2)
+
+	//auto join in conditions
+	var friends []*testFriend
+	c.Assert(s.db.Where("friends.name = ?", "second").Order("id", ASC).Find(&friends), IsNil)
+	c.Assert(friends, HasLen, 2)
+	c.Assert(friends[0].Id, Equals, 1)
+	c.Assert(friends[1].Id, Equals, 3)
+
+	c.Assert(s.db.DropTable((*testFriend)(nil)), IsNil)
+	_, err = s.db.DB().Exec("SELECT * FROM `test_friendship`")
+	c.Assert(err, NotNil)
+}
+
+func (s *stormSuite) TestManyToMany_ErrorSelfReferencingColumns(c *C) {
+	c.Assert(s.db.RegisterStructure((*testInvalidFriend)(nil)), ErrorMatches, "many to many relation `friends` of table `test_invalid_friend` links both sides with column `test_invalid_friend_id`, use joinkey\\(\\) and joinrelkey\\(\\) to name the columns")
+	_, ok := s.db.table(reflect.TypeOf(testInvalidFriend{}))
+	c.Assert(ok, Equals, false)
+}
+
+func (s *stormSuite) TestUpsert(c *C) {
+	c.Assert(s.db.RegisterStructure((*testUpsertStructure)(nil)), IsNil)
+	_, err := s.db.DB().Exec("CREATE TABLE `test_upsert_structure` (`id` INTEGER PRIMARY KEY, `email` TEXT UNIQUE, `name` TEXT, `score` INTEGER)")
+	c.Assert(err, IsNil)
+	_, err = s.db.DB().Exec("INSERT INTO `test_upsert_structure` (`id`, `email`, `name`, `score`) VALUES (1, 'first@example.com', 'first', 1)")
+	c.Assert(err, IsNil)
+
+	//insert
+	input := &testUpsertStructure{Email: "second@example.com", Name: "second", Score: 2}
+	c.Assert(s.db.Upsert(&input, OnConflict("email").Update("name")), IsNil)
+	c.Assert(input.Id, Equals, 2)
+	c.Assert(input.onInsertInvoked, Equals, true)
+	c.Assert(input.onUpdateInvoked, Equals, false)
+
+	//update only the name on the existing record, the id is refreshed
+	input = &testUpsertStructure{Email: "first@example.com", Name: "first updated", Score: 10}
+	c.Assert(s.db.Upsert(&input, OnConflict("Email").Update("Name")), IsNil)
+	c.Assert(input.Id, Equals, 1)
+	c.Assert(input.onInsertInvoked, Equals, false)
+	c.Assert(input.onUpdateInvoked, Equals, true)
+
+	var compare *testUpsertStructure
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+	c.Assert(compare.Name, Equals, "first updated")
+	c.Assert(compare.Score, Equals, 1)
+
+	//conflict on the primary key without updates
+	input = &testUpsertStructure{Id: 2, Email: "other@example.com", Name: "ignored"}
+	c.Assert(s.db.Upsert(&input, nil), IsNil)
+	c.Assert(s.db.Find(&compare, 2), IsNil)
+	c.Assert(compare.Name, Equals, "second")
+
+	cnt, err := s.db.Query().Count((*testUpsertStructure)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(2))
+
+	//a new record conflicting on the auto increment id is inserted with a generated id
+	input = &testUpsertStructure{Email: "third@example.com", Name: "third"}
+	c.Assert(s.db.Upsert(&input, nil), IsNil)
+	c.Assert(input.Id, Equals, 3)
+	c.Assert(input.onInsertInvoked, Equals, true)
+	c.Assert(s.db.Find(&compare, 3), IsNil)
+	c.Assert(compare.Name, Equals, "third")
+}
+
+func (s *stormSuite) TestUpsert_ErrorUnknownColumn(c *C) {
+	c.Assert(s.db.RegisterStructure((*testUpsertStructure)(nil)), IsNil)
+	c.Assert(s.db.Upsert(&testUpsertStructure{}, OnConflict("mail")), ErrorMatches, "unknown column `mail` in table `test_upsert_structure`")
+	c.Assert(s.db.Upsert(&testUpsertStructure{}, OnConflict("email").Update("Nme")), ErrorMatches, "unknown column `Nme` in table `test_upsert_structure`")
+}
+
+func (s *stormSuite) TestUpsert_ErrorNotSupported(c *C) {
+	db, err := OpenDB(s.db.db, dialect.New("mssql"))
+	c.Assert(err, IsNil)
+	c.Assert(db.RegisterStructure((*testUpsertStructure)(nil)), IsNil)
+	c.Assert(db.Upsert(&testUpsertStructure{}, OnConflict("email")), ErrorMatches, "dialect `mssql` does not support upsert")
+}
+
 func (s *stormSuite) TestSave_AllSupportedTypes(c *C) {
 
 	//Time:time.Time{sec:63429436799, nsec:0, loc:(*time.Location)(0xad2400)}, Byte:[]uint8{0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x30}
@@ -391,6 +1194,387 @@ func (s *stormSuite) TestSave_AllSupportedTypes(c *C) {
 	c.Assert(compare, DeepEquals, input)
 }
 
+func (s *stormSuite) TestSave_CompositeKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testCompositeKey)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testCompositeKey)(nil)), IsNil)
+
+	//insert
+	input := &testCompositeKey{CustomerId: 1, TagId: 2, Position: 3}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(s.db.Save(&testCompositeKey{CustomerId: 1, TagId: 3, Position: 4}), IsNil)
+
+	//update
+	input.Position = 5
+	c.Assert(s.db.Save(&input), IsNil)
+
+	cnt, err := s.db.Query().Count((*testCompositeKey)(nil))
+	c.Assert(err, IsNil)
+	c.Assert(cnt, Equals, int64(2))
+
+	var compare *testCompositeKey
+	c.Assert(s.db.Find(&compare, 1, 2), IsNil)
+	c.Assert(compare, DeepEquals, input)
+
+	//delete by all keys
+	c.Assert(s.db.Delete(&input), IsNil)
+	c.Assert(s.db.Find(&compare, 1, 2), Equals, sql.ErrNoRows)
+	c.Assert(s.db.Find(&compare, 1, 3), IsNil)
+	c.Assert(compare.Position, Equals, 4)
+}
+
+func (s *stormSuite) TestFind_CompositeStringKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testNaturalPopulation)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testNaturalPopulation)(nil)), IsNil)
+
+	input := &testNaturalPopulation{CountryCode: "NL", Year: 2020, Population: 17}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(s.db.Save(&testNaturalPopulation{CountryCode: "NL", Year: 2021, Population: 18}), IsNil)
+
+	var compare *testNaturalPopulation
+	c.Assert(s.db.Find(&compare, "NL", 2020), IsNil)
+	c.Assert(compare, DeepEquals, input)
+	c.Assert(s.db.Find(&compare, "NL", 2022), Equals, sql.ErrNoRows)
+
+	//a condition with a binding of the same length is not a key tuple
+	c.Assert(s.db.Find(&compare, "year = ?", 2021), IsNil)
+	c.Assert(compare.Population, Equals, 18)
+}
+
+func (s *stormSuite) TestFind_CompositeKeyErrorKeyCount(c *C) {
+	c.Assert(s.db.RegisterStructure((*testCompositeKey)(nil)), IsNil)
+
+	var compare *testCompositeKey
+	c.Assert(s.db.Find(&compare, 1), ErrorMatches, "expected 2 primary key values for find, got 1")
+}
+
+func (s *stormSuite) TestRelation_NaturalKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testNaturalCountry)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testNaturalAddress)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testNaturalCountry)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testNaturalAddress)(nil)), IsNil)
+
+	_, err := s.db.DB().Exec("INSERT INTO `test_natural_country` (`code`, `name`) VALUES (31, 'nl'), (49, 'de')")
+	c.Assert(err, IsNil)
+	c.Assert(s.db.Save(&testNaturalAddress{Line: "line 1", CountryId: 49}), IsNil)
+	c.Assert(s.db.Save(&testNaturalAddress{Line: "line 2", CountryId: 31}), IsNil)
+
+	//join on the key of the related table
+	var address *testNaturalAddress
+	c.Assert(s.db.Where("country.name = ?", "nl").First(&address), IsNil)
+	c.Assert(address.Line, Equals, "line 2")
+
+	c.Assert(s.db.Dependent(&address, "Country"), IsNil)
+	c.Assert(address.Country, DeepEquals, &testNaturalCountry{Code: 31, Name: "nl"})
+
+	address = nil
+	c.Assert(s.db.Where("id = ?", 1).DependentColumns("Country").First(&address), IsNil)
+	c.Assert(address.Country, DeepEquals, &testNaturalCountry{Code: 49, Name: "de"})
+}
+
+func (s *stormSuite) TestRelation_TaggedForeignKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testAccount)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testTransfer)(nil)), IsNil)
+
+	tblAccount, _ := s.db.table(reflect.TypeOf(testAccount{}))
+	tblTransfer, _ := s.db.table(reflect.TypeOf(testTransfer{}))
+	c.Assert(tblTransfer.relations, HasLen, 2)
+	c.Assert(tblTransfer.relations[0].relTable, IsNil)
+	c.Assert(tblTransfer.relations[0].relColumn, Equals, tblTransfer.findColumn("source_code"))
+	c.Assert(tblTransfer.relations[0].refColumn, Equals, tblAccount.findColumn("code"))
+	c.Assert(tblTransfer.relations[1].relColumn, Equals, tblTransfer.findColumn("target_code"))
+	c.Assert(tblTransfer.relations[1].refColumn, Equals, tblAccount.findColumn("code"))
+	c.Assert(tblAccount.relations[0].relTable, Equals, tblTransfer)
+	c.Assert(tblAccount.relations[0].relColumn, Equals, tblTransfer.findColumn("source_code"))
+
+	c.Assert(s.db.CreateTable((*testAccount)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testTransfer)(nil)), IsNil)
+	c.Assert(s.db.InsertAll(&[]testAccount{{Code: "A01", Name: "checking"}, {Code: "A02", Name: "savings"}}), IsNil)
+	c.Assert(s.db.InsertAll(&[]testTransfer{
+		{Amount: 10, SourceCode: "A01", TargetCode: "A02"},
+		{Amount: 20, SourceCode: "A02", TargetCode: "A01"},
+		{Amount: 30, SourceCode: "A01", TargetCode: "A02"},
+	}), IsNil)
+
+	//join on the referenced column
+	var transfers []*testTransfer
+	c.Assert(s.db.Where("target.name = ?", "savings").Order("id", ASC).Find(&transfers), IsNil)
+	c.Assert(transfers, HasLen, 2)
+	c.Assert(transfers[0].Amount, Equals, 10)
+	c.Assert(transfers[1].Amount, Equals, 30)
+
+	var transfer *testTransfer
+	c.Assert(s.db.Find(&transfer, 2), IsNil)
+	c.Assert(s.db.Dependent(&transfer, "Source", "Target"), IsNil)
+	c.Assert(transfer.Source.Name, Equals, "savings")
+	c.Assert(transfer.Target.Name, Equals, "checking")
+
+	transfer = nil
+	c.Assert(s.db.Where("id = ?", 1).DependentColumns("Source", "Target").First(&transfer), IsNil)
+	c.Assert(transfer.Source.Name, Equals, "checking")
+	c.Assert(transfer.Target.Name, Equals, "savings")
+
+	var account *testAccount
+	c.Assert(s.db.Find(&account, "code = ?", "A01"), IsNil)
+	c.Assert(s.db.Dependent(&account, "Transfers"), IsNil)
+	c.Assert(account.Transfers, HasLen, 2)
+	c.Assert(account.Transfers[0].Amount, Equals, 10)
+	c.Assert(account.Transfers[1].Amount, Equals, 30)
+}
+
+func (s *stormSuite) TestRelation_TaggedForeignKeyNotResolved(c *C) {
+	c.Assert(s.db.RegisterStructure((*testAccount)(nil)), IsNil)
+
+	c.Assert(s.db.RegisterStructure((*testInvalidForeignKey)(nil)), ErrorMatches, "cannot resolve foreign key `account_code` of relation `account` in table `test_invalid_foreign_key`")
+	_, ok := s.db.table(reflect.TypeOf(testInvalidForeignKey{}))
+	c.Assert(ok, Equals, false)
+
+	c.Assert(s.db.RegisterStructure((*testInvalidReference)(nil)), ErrorMatches, "cannot resolve referenced column `number` of relation `account` in table `test_invalid_reference`")
+	_, ok = s.db.table(reflect.TypeOf(testInvalidReference{}))
+	c.Assert(ok, Equals, false)
+}
+
+//noRecursiveDialect disables the recursive common table expressions of the wrapped dialect
+type noRecursiveDialect struct {
+	dialect.Dialect
+}
+
+func (d noRecursiveDialect) Capabilities() dialect.Capabilities {
+	capabilities := d.Dialect.Capabilities()
+	capabilities.Recursive = false
+	return capabilities
+}
+
+func testCategoryTree(c *C, db *Storm) {
+	c.Assert(db.RegisterStructure((*testCategory)(nil)), IsNil)
+	c.Assert(db.CreateTable((*testCategory)(nil)), IsNil)
+	_, err := db.DB().Exec("INSERT INTO `test_category` (`id`, `name`, `parent_id`) VALUES " +
+		"(1, 'root', NULL), (2, 'a', 1), (3, 'b', 1), (4, 'a1', 2), (5, 'b1', 3), (6, 'a1x', 4), (7, 'other', NULL), " +
+		"(8, 'cycle', 10), (9, 'c1', 8), (10, 'c2', 9)")
+	c.Assert(err, IsNil)
+
+	var root *testCategory
+	c.Assert(db.Find(&root, 1), IsNil)
+	c.Assert(db.DependentRecursive(&root, Recursive("Children", 2)), IsNil)
+	c.Assert(root.Children, HasLen, 2)
+	c.Assert(root.Children[0].Name, Equals, "a")
+	c.Assert(root.Children[0].Children, HasLen, 1)
+	c.Assert(root.Children[0].Children[0].Name, Equals, "a1")
+	c.Assert(root.Children[0].Children[0].Children, HasLen, 0)
+	c.Assert(root.Children[1].Name, Equals, "b")
+	c.Assert(root.Children[1].Children, HasLen, 1)
+	c.Assert(root.Children[1].Children[0].Name, Equals, "b1")
+
+	//the whole subtree
+	c.Assert(db.DependentRecursive(&root, Recursive("Children", 0)), IsNil)
+	c.Assert(root.Children, HasLen, 2)
+	c.Assert(root.Children[0].Children[0].Children, HasLen, 1)
+	c.Assert(root.Children[0].Children[0].Children[0].Name, Equals, "a1x")
+	c.Assert(root.Children[0].Children[0].Children[0].Children, HasLen, 0)
+
+	var leaf *testCategory
+	c.Assert(db.Find(&leaf, 6), IsNil)
+	c.Assert(db.DependentRecursive(&leaf, Recursive("Children", 0)), IsNil)
+	c.Assert(leaf.Children, HasLen, 0)
+
+	//a cycle stops at the records already loaded
+	var cycle *testCategory
+	c.Assert(db.Find(&cycle, 8), IsNil)
+	c.Assert(db.DependentRecursive(&cycle, Recursive("Children", 0)), IsNil)
+	c.Assert(cycle.Children, HasLen, 1)
+	c.Assert(cycle.Children[0].Name, Equals, "c1")
+	c.Assert(cycle.Children[0].Children, HasLen, 1)
+	c.Assert(cycle.Children[0].Children[0].Name, Equals, "c2")
+	c.Assert(cycle.Children[0].Children[0].Children, HasLen, 0)
+}
+
+func (s *stormSuite) TestDependent_Recursive(c *C) {
+	c.Assert(s.db.Dialect().Capabilities().Recursive, Equals, true)
+	testCategoryTree(c, s.db)
+}
+
+func (s *stormSuite) TestDependent_RecursiveLevels(c *C) {
+	db, err := OpenWithDialect(`sqlite3`, `:memory:`, noRecursiveDialect{dialect.New("sqlite3")})
+	c.Assert(err, IsNil)
+	defer db.Close()
+	testCategoryTree(c, db)
+}
+
+func (s *stormSuite) TestDependent_RecursiveErrors(c *C) {
+	c.Assert(s.db.RegisterStructure((*testCategory)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testAccount)(nil)), IsNil)
+	c.Assert(s.db.RegisterStructure((*testTransfer)(nil)), IsNil)
+
+	root := &testCategory{Id: 1}
+	c.Assert(s.db.DependentRecursive(root, Recursive("Parent", 1)), ErrorMatches, "unknown relation `Parent` in table `test_category`")
+	c.Assert(s.db.DependentRecursive(testCategory{}, Recursive("Children", 1)), ErrorMatches, "provided input is not by reference")
+
+	account := &testAccount{Id: 1}
+	c.Assert(s.db.DependentRecursive(account, Recursive("Transfers", 1)), ErrorMatches, "relation `transfers` of table `test_account` is not a self referencing one to many relation")
+}
+
+func (s *stormSuite) TestSave_GeneratedKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testUuidKey)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testUuidKey)(nil)), IsNil)
+
+	//insert generates the key
+	input := &testUuidKey{Name: "test"}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Id, HasLen, 36)
+
+	//update
+	input.Name = "test updated"
+	c.Assert(s.db.Save(&input), IsNil)
+
+	//client side keys are inserted when not existing
+	c.Assert(s.db.Save(&testUuidKey{Id: "preset", Name: "preset"}), IsNil)
+
+	var compares []*testUuidKey
+	c.Assert(s.db.Order("name", ASC).Find(&compares), IsNil)
+	c.Assert(compares, DeepEquals, []*testUuidKey{{Id: "preset", Name: "preset"}, input})
+
+	var compare *testUuidKey
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare, DeepEquals, input)
+	c.Assert(s.db.Find(&compare, "preset"), IsNil)
+	c.Assert(compare.Name, Equals, "preset")
+	c.Assert(s.db.Find(&compare, "unknown"), Equals, sql.ErrNoRows)
+
+	//conditions are still applied as a where clause
+	c.Assert(s.db.Find(&compare, "name = ?", "preset"), IsNil)
+	c.Assert(compare.Id, Equals, "preset")
+}
+
+func (s *stormSuite) TestSave_GeneratedBinaryKey(c *C) {
+	c.Assert(s.db.RegisterStructure((*testBinaryKey)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testBinaryKey)(nil)), IsNil)
+
+	input := &testBinaryKey{Name: "test"}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Id, HasLen, 16)
+
+	var compare *testBinaryKey
+	c.Assert(s.db.Find(&compare, input.Id), IsNil)
+	c.Assert(compare, DeepEquals, input)
+}
+
+func (s *stormSuite) TestSave_RegisteredGenerator(c *C) {
+	type testCustomKey struct {
+		Id   int64 `db:"pk,gen(test_storm_sequence)"`
+		Name string
+	}
+
+	registerGenerators()
+	testSequence = 100
+
+	c.Assert(s.db.RegisterStructure((*testCustomKey)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testCustomKey)(nil)), IsNil)
+
+	input := &testCustomKey{Name: "test"}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Id, Equals, int64(101))
+
+	var compare *testCustomKey
+	c.Assert(s.db.Find(&compare, 101), IsNil)
+	c.Assert(compare, DeepEquals, input)
+}
+
+func (s *stormSuite) TestSave_ErrorUnknownGenerator(c *C) {
+	type testUnknownGenerator struct {
+		Id string `db:"pk,gen(unknown)"`
+	}
+
+	c.Assert(s.db.RegisterStructure((*testUnknownGenerator)(nil)), IsNil)
+	c.Assert(s.db.Save(&testUnknownGenerator{}), ErrorMatches, "unknown key generator `unknown`")
+}
+
+func (s *stormSuite) TestSave_NamedEmbed(c *C) {
+	c.Assert(s.db.RegisterStructure((*testEmbedStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testEmbedStructure)(nil)), IsNil)
+
+	input := &testEmbedStructure{
+		Name:     "test",
+		Billing:  testEmbedAddress{Street: "Billing street 1", City: "Amsterdam", CountryCode: "NL"},
+		Shipping: testEmbedAddress{Street: "Shipping street 2", City: "Berlin", CountryCode: "DE"},
+	}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Id, Equals, 1)
+
+	var city string
+	c.Assert(s.db.DB().QueryRow("SELECT `shipping_city` FROM `test_embed_structure` WHERE `billing_city` = 'Amsterdam'").Scan(&city), IsNil)
+	c.Assert(city, Equals, "Berlin")
+
+	//update
+	input.Shipping.City = "Paris"
+	c.Assert(s.db.Save(&input), IsNil)
+
+	var compare *testEmbedStructure
+	c.Assert(s.db.Where("shipping_country = ?", "DE").First(&compare), IsNil)
+	c.Assert(compare, DeepEquals, input)
+}
+
+func (s *stormSuite) TestSave_JSON(c *C) {
+	c.Assert(s.db.RegisterStructure((*testJsonStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testJsonStructure)(nil)), IsNil)
+
+	input := &testJsonStructure{
+		Settings: testJsonSettings{Theme: "dark", Notify: true},
+		Tags:     []string{"a", "b"},
+		Meta:     map[string]interface{}{"key": "value", "count": float64(2)},
+	}
+	c.Assert(s.db.Save(&input), IsNil)
+	c.Assert(input.Id, Equals, 1)
+
+	var raw sql.NullString
+	c.Assert(s.db.DB().QueryRow("SELECT `settings` FROM `test_json_structure`").Scan(&raw), IsNil)
+	c.Assert(raw.String, Equals, `{"Theme":"dark","Notify":true}`)
+	c.Assert(s.db.DB().QueryRow("SELECT `settings_ptr` FROM `test_json_structure`").Scan(&raw), IsNil)
+	c.Assert(raw.Valid, Equals, false) //nil values are stored as NULL
+
+	var compare *testJsonStructure
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+	c.Assert(compare, DeepEquals, input)
+
+	//update
+	input.SettingsPtr = &testJsonSettings{Theme: "light"}
+	input.Tags = nil
+	c.Assert(s.db.Save(&input), IsNil)
+
+	var compares []testJsonStructure
+	c.Assert(s.db.Find(&compares), IsNil)
+	c.Assert(compares, HasLen, 1)
+	c.Assert(&compares[0], DeepEquals, input)
+}
+
+func (s *stormSuite) TestUpdate_SetJSON(c *C) {
+	c.Assert(s.db.RegisterStructure((*testJsonStructure)(nil)), IsNil)
+	c.Assert(s.db.CreateTable((*testJsonStructure)(nil)), IsNil)
+
+	input := &testJsonStructure{Tags: []string{"a"}, SettingsPtr: &testJsonSettings{Theme: "dark"}}
+	c.Assert(s.db.Save(&input), IsNil)
+
+	n, err := s.db.Query().Update((*testJsonStructure)(nil), Set{
+		"settings":     testJsonSettings{Theme: "light", Notify: true},
+		"settings_ptr": nil,
+		"tags":         []string{"b", "c"},
+		"metadata":     map[string]interface{}{"key": "value"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(1))
+
+	var raw sql.NullString
+	c.Assert(s.db.DB().QueryRow("SELECT `settings` FROM `test_json_structure`").Scan(&raw), IsNil)
+	c.Assert(raw.String, Equals, `{"Theme":"light","Notify":true}`)
+
+	var compare *testJsonStructure
+	c.Assert(s.db.Find(&compare, 1), IsNil)
+	c.Assert(compare, DeepEquals, &testJsonStructure{
+		Id:       1,
+		Settings: testJsonSettings{Theme: "light", Notify: true},
+		Tags:     []string{"b", "c"},
+		Meta:     map[string]interface{}{"key": "value"},
+	})
+}
+
 func (s *stormSuite) TestSave_ErrorNotByReference(c *C) {
 	c.Assert(s.db.Save(Person{}), ErrorMatches, "provided input is not by reference")
 }
@@ -553,7 +1737,9 @@ func (s *stormSuite) TestGenerateCreateTableSQL(c *C) {
 	c.Assert(ok, Equals, true)
 	c.Assert(tbl, NotNil)
 
-	c.Assert(s.db.generateCreateTableSQL(tbl), Equals, "CREATE TABLE `test_all_type_structure` ("+
+	sqlQuery, err := s.db.generateCreateTableSQL(tbl)
+	c.Assert(err, IsNil)
+	c.Assert(sqlQuery, Equals, "CREATE TABLE `test_all_type_structure` ("+
 		"`id` INTEGER PRIMARY KEY,"+
 		"`test_custom_type` INTEGER,"+
 		"`time` DATETIME,"+
@@ -575,6 +1761,52 @@ func (s *stormSuite) TestGenerateCreateTableSQL(c *C) {
 		")")
 }
 
+func (s *stormSuite) TestGenerateCreateTableSQL_RegisteredTypes(c *C) {
+	dialect.RegisterType("sqlite3", testUUID{}, "CHAR(36)")
+	dialect.RegisterType("sqlite3", (*testGeometry)(nil), "GEOMETRY")
+
+	type testRegisteredTypeStructure struct {
+		Id       int
+		Uuid     testUUID
+		UuidPtr  *testUUID
+		Point    testPoint
+		Duration time.Duration
+		Status   testStatus
+		NullBool null.Bool
+	}
+
+	c.Assert(s.db.RegisterStructure((*testRegisteredTypeStructure)(nil)), IsNil)
+	tbl, ok := s.db.table(reflect.TypeOf((*testRegisteredTypeStructure)(nil)).Elem())
+	c.Assert(ok, Equals, true)
+
+	sqlQuery, err := s.db.generateCreateTableSQL(tbl)
+	c.Assert(err, IsNil)
+	c.Assert(sqlQuery, Equals, "CREATE TABLE `test_registered_type_structure` ("+
+		"`id` INTEGER PRIMARY KEY,"+
+		"`uuid` CHAR(36),"+
+		"`uuid_ptr` CHAR(36),"+
+		"`point` GEOMETRY,"+
+		"`duration` BIGINT,"+
+		"`status` TEXT,"+
+		"`null_bool` BOOL"+
+		")")
+}
+
+func (s *stormSuite) TestGenerateCreateTableSQL_ErrorUnknownType(c *C) {
+	type testUnknownTypeStructure struct {
+		Id       int
+		Settings map[string]string
+	}
+
+	c.Assert(s.db.RegisterStructure((*testUnknownTypeStructure)(nil)), IsNil)
+	tbl, ok := s.db.table(reflect.TypeOf((*testUnknownTypeStructure)(nil)).Elem())
+	c.Assert(ok, Equals, true)
+
+	_, err := s.db.generateCreateTableSQL(tbl)
+	c.Assert(err, ErrorMatches, "cannot create column `settings`: invalid sql type for sqlite3 \\(map\\[string\\]string\\)")
+	c.Assert(s.db.CreateTable((*testUnknownTypeStructure)(nil)), ErrorMatches, "cannot create column `settings`: .*")
+}
+
 func (s *stormSuite) TestGenerateDropTableSQL(c *C) {
 	c.Assert(s.db.RegisterStructure((*Person)(nil)), IsNil)
 	tbl, ok := s.db.table(reflect.TypeOf((*Person)(nil)).Elem())
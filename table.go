@@ -3,8 +3,11 @@ package storm
 import (
 	"bytes"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,16 +17,27 @@ type column struct {
 	settings   map[string]string
 	goType     reflect.Type
 	goIndex    []int
+	size       int
 	isScanner  bool
+	isJSON     bool
 }
 
 type relation struct {
 	name           string
+	settings       map[string]string
 	relTable       *table
 	relColumn      *column
 	goType         reflect.Type
 	goSingularType reflect.Type
 	goIndex        []int
+
+	//referenced column of the foreign key when tagged with `references`, the primary key otherwise
+	refColumn *column
+
+	//many to many relations are linked by the keys of both tables in the join table
+	joinTable  string
+	joinKey    string
+	joinRelKey string
 }
 
 type table struct {
@@ -34,14 +48,36 @@ type table struct {
 	keys      []*column
 	aiColumn  *column
 	callbacks callback
+
+	//columns with a special purpose, nil when not present
+	genColumn     *column
+	versionColumn *column
+	createdColumn *column
+	updatedColumn *column
+	deletedColumn *column
+
+	snapshotIndex []int
 }
 
-func newTable(v reflect.Value) *table {
+func newTable(v reflect.Value) (*table, error) {
 
 	//read the structure
-	cols, rels := extractStructColumns(reflect.Indirect(v), nil)
+	cols, rels, err := extractStructColumns(reflect.Indirect(v), nil)
+	if err != nil {
+		return nil, err
+	}
 	pks := findPKs(cols)
 
+	versionColumn, err := findVersion(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedColumn, err := findSoftDelete(cols)
+	if err != nil {
+		return nil, err
+	}
+
 	//scan for callbacks
 	cb := make(callback)
 	cb.registerCallback(v, "OnInsert")
@@ -56,14 +92,98 @@ func newTable(v reflect.Value) *table {
 
 	//create the table structure
 	return &table{
-		tableName: camelToSnake(t.Name()),
-		goType:    t,
-		columns:   cols,
-		relations: rels,
-		keys:      pks,
-		aiColumn:  findAI(cols, pks),
-		callbacks: cb,
+		tableName:     camelToSnake(t.Name()),
+		goType:        t,
+		columns:       cols,
+		relations:     rels,
+		keys:          pks,
+		aiColumn:      findAI(cols, pks),
+		genColumn:     findGen(pks),
+		versionColumn: versionColumn,
+		createdColumn: findTimestamp(cols, "created", "created_at"),
+		updatedColumn: findTimestamp(cols, "updated", "updated_at"),
+		deletedColumn: deletedColumn,
+		callbacks:     cb,
+		snapshotIndex: findSnapshot(t),
+	}, nil
+}
+
+//isKey checks if the column is part of the primary key
+func (t *table) isKey(col *column) bool {
+	for _, key := range t.keys {
+		if key == col {
+			return true
+		}
+	}
+	return false
+}
+
+//primaryKey returns the column referenced by relations, nil when the table has no or a composite primary key
+func (t *table) primaryKey() *column {
+	if t.aiColumn != nil {
+		return t.aiColumn
+	}
+	if len(t.keys) == 1 {
+		return t.keys[0]
+	}
+	return nil
+}
+
+//referencedKey returns the column the foreign key of the relation references in the referenced table
+func (rel *relation) referencedKey(refTbl *table) *column {
+	if rel.refColumn != nil {
+		return rel.refColumn
+	}
+	return refTbl.primaryKey()
+}
+
+//isTagged checks if the relation has an explicit foreign key or referenced column
+func (rel *relation) isTagged() bool {
+	_, fk := rel.settings["fk"]
+	_, ref := rel.settings["references"]
+	return fk || ref
+}
+
+//findColumn finds the column by its column or field name
+func (t *table) findColumn(name string) *column {
+	for _, col := range t.columns {
+		if strings.EqualFold(col.columnName, name) || t.goType.FieldByIndex(col.goIndex).Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+//findRelation finds the relation by its name or field name
+func (t *table) findRelation(name string) *relation {
+	for _, rel := range t.relations {
+		if strings.EqualFold(rel.name, camelToSnake(name)) || t.goType.FieldByIndex(rel.goIndex).Name == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+//resolveColumns finds the columns by their column or field names, unknown names are rejected
+func (t *table) resolveColumns(names []string) ([]*column, error) {
+	cols := make([]*column, 0, len(names))
+	for _, name := range names {
+		col := t.findColumn(name)
+		if col == nil {
+			return nil, fmt.Errorf("unknown column `%s` in table `%s`", name, t.tableName)
+		}
+		cols = append(cols, col)
 	}
+	return cols, nil
+}
+
+func containsColumn(cols []*column, col *column) bool {
+	for _, c := range cols {
+		if c == col {
+			return true
+		}
+	}
+	return false
 }
 
 // Parse structure tags like "tagname, tagname(property)" into a map
@@ -85,7 +205,7 @@ func parseTags(s string) map[string]string {
 }
 
 // read out the structure and return the column map
-func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []*relation) {
+func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []*relation, err error) {
 
 	t := v.Type()
 	n := t.NumField()
@@ -94,7 +214,10 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 
 		if f.Anonymous && f.Type.Kind() == reflect.Struct {
 			//if the embeded element is a structure ignore it for now
-			subcols, subrels := extractStructColumns(v.Field(i), append(index, f.Index...))
+			subcols, subrels, err := extractStructColumns(v.Field(i), fieldIndex(index, f.Index))
+			if err != nil {
+				return nil, nil, err
+			}
 			cols = append(cols, subcols...)
 			rels = append(rels, subrels...)
 			continue
@@ -112,9 +235,34 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 			}
 			t := f.Type
 
+			//named value objects are flattened into the owning table, prefixing the column names
+			if _, ok := tags["embed"]; ok {
+				if f.Type.Kind() != reflect.Struct {
+					return nil, nil, fmt.Errorf("cannot embed field `%s`, only structs can be embedded", f.Name)
+				}
+
+				subcols, subrels, err := extractStructColumns(v.Field(i), fieldIndex(index, f.Index))
+				if err != nil {
+					return nil, nil, err
+				}
+				prefix := tags["prefix"]
+				for _, col := range subcols {
+					col.columnName = prefix + col.columnName
+				}
+				for _, rel := range subrels {
+					rel.name = prefix + rel.name
+				}
+				cols = append(cols, subcols...)
+				rels = append(rels, subrels...)
+				continue
+			}
+
+			//json columns are stored encoded in a single column
+			_, isJSONCol := tags["json"]
+
 			//slices are threat like relational one to many (expect byte slices)
 			isScannerCol := isScanner(f.Type)
-			if f.Type.Kind() == reflect.Slice && f.Type != reflect.TypeOf([]byte{}) {
+			if !isJSONCol && f.Type.Kind() == reflect.Slice && f.Type != reflect.TypeOf([]byte{}) {
 
 				//get the singular type for table lookup
 				bt := t.Elem()
@@ -124,20 +272,22 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 
 				rels = append(rels, &relation{
 					name:           columnName,
+					settings:       tags,
 					goType:         t,
 					goSingularType: bt,
-					goIndex:        append(index, f.Index...),
+					goIndex:        fieldIndex(index, f.Index),
 				})
 				continue
 
 				//all structs are handled as relations / except when they implements the scanner interface
-			} else if !isScannerCol && !isTime(f.Type) && (f.Type.Kind() == reflect.Struct || (f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct)) {
+			} else if !isJSONCol && !isScannerCol && !isTime(typeIndirect(f.Type)) && (f.Type.Kind() == reflect.Struct || (f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct)) {
 
 				rels = append(rels, &relation{
 					name:           columnName,
+					settings:       tags,
 					goType:         t,
 					goSingularType: t,
-					goIndex:        append(index, f.Index...),
+					goIndex:        fieldIndex(index, f.Index),
 				})
 				continue
 			}
@@ -150,11 +300,22 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 				case "string":
 					t = reflect.TypeOf(string(""))
 				default:
-					panic(fmt.Sprintf("unkown override type `%s`", overideType))
+					return nil, nil, fmt.Errorf("unkown override type `%s`", overideType)
 				}
 
 				if !f.Type.ConvertibleTo(t) {
-					panic(fmt.Sprintf("cannot override type `%s` with `%s`", f.Type, t))
+					return nil, nil, fmt.Errorf("cannot override type `%s` with `%s`", f.Type, t)
+				}
+			}
+
+			//column size used on table creation, keys have a default size
+			size := 0
+			if _, ok := tags["pk"]; ok {
+				size = keySize(tags["gen"], t)
+			}
+			if sizeTag, ok := tags["size"]; ok {
+				if size, err = strconv.Atoi(sizeTag); err != nil {
+					return nil, nil, fmt.Errorf("invalid size `%s` for column `%s`", sizeTag, columnName)
 				}
 			}
 
@@ -162,8 +323,10 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 				columnName: columnName,
 				settings:   tags,
 				goType:     t,
-				goIndex:    append(index, f.Index...),
+				goIndex:    fieldIndex(index, f.Index),
+				size:       size,
 				isScanner:  isScannerCol,
+				isJSON:     isJSONCol,
 			})
 		}
 	}
@@ -171,11 +334,76 @@ func extractStructColumns(v reflect.Value, index []int) (cols []*column, rels []
 	return
 }
 
-//find primary keys
+//fieldIndex returns a new index path, sub structures never share the backing array of their parent
+func fieldIndex(index []int, sub []int) []int {
+	return append(append(make([]int, 0, len(index)+len(sub)), index...), sub...)
+}
+
+//bindValue returns the value of the column in structure v to bind to a statement
+func (col *column) bindValue(v reflect.Value) interface{} {
+	field := v.FieldByIndex(col.goIndex)
+	if col.isJSON {
+		return jsonValue{field}
+	}
+	return field.Interface()
+}
+
+//scanDest returns the scan destination of the column in structure v
+func (col *column) scanDest(v reflect.Value) interface{} {
+	field := v.FieldByIndex(col.goIndex).Addr().Interface()
+	if col.isJSON {
+		return &jsonScanner{field}
+	}
+	return field
+}
+
+//jsonValue encodes the field as json when bound to a statement, nil values are stored as NULL
+type jsonValue struct {
+	field reflect.Value
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	switch j.field.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if j.field.IsNil() {
+			return nil, nil
+		}
+	}
+
+	b, err := json.Marshal(j.field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+//jsonScanner decodes a json column into the destination, NULL resets the destination to the zero value
+type jsonScanner struct {
+	dst interface{}
+}
+
+func (j *jsonScanner) Scan(value interface{}) error {
+	v := reflect.ValueOf(j.dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, j.dst)
+	case string:
+		return json.Unmarshal([]byte(data), j.dst)
+	}
+	return fmt.Errorf("cannot decode json column from %T", value)
+}
+
+//find primary keys, tagged keys can be of any type
 func findPKs(cols []*column) (pks []*column) {
 
 	for _, col := range cols {
-		if _, ok := col.settings["pk"]; ok && (col.goType.Kind() == reflect.Int || col.goType.Kind() == reflect.Int64) {
+		if _, ok := col.settings["pk"]; ok {
 			pks = append(pks, col)
 		}
 	}
@@ -204,9 +432,79 @@ func findAI(cols []*column, pks []*column) *column {
 		}
 	}
 
-	//fallback
-	if len(pks) == 1 {
-		return pks[0]
+	//fallback on a single integer key without a generator
+	if len(pks) == 1 && (pks[0].goType.Kind() == reflect.Int || pks[0].goType.Kind() == reflect.Int64) {
+		if _, ok := pks[0].settings["gen"]; !ok {
+			return pks[0]
+		}
+	}
+	return nil
+}
+
+//find the integer column used for optimistic locking
+func findVersion(cols []*column) (*column, error) {
+	for _, col := range cols {
+		if _, ok := col.settings["version"]; ok {
+			switch col.goType.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return col, nil
+			}
+			return nil, fmt.Errorf("version column `%s` must be an integer, got `%s`", col.columnName, col.goType)
+		}
+	}
+	return nil, nil
+}
+
+//find the time column filled on insert or update by its tag, or else by its column name
+func findTimestamp(cols []*column, tag string, columnName string) *column {
+	for _, col := range cols {
+		if _, ok := col.settings[tag]; ok {
+			return col
+		}
+	}
+
+	for _, col := range cols {
+		if col.columnName == columnName && isTime(typeIndirect(col.goType)) {
+			return col
+		}
+	}
+	return nil
+}
+
+//hasSetting checks if one of the values of the tag setting equals the value, values are separated by a pipe
+//Example: `db:"cascade(save|delete)"`
+func hasSetting(settings map[string]string, name string, value string) bool {
+	setting, ok := settings[name]
+	if !ok {
+		return false
+	}
+	for _, v := range strings.Split(setting, "|") {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//find the soft delete column, a deleted record has a timestamp in this column
+func findSoftDelete(cols []*column) (*column, error) {
+	for _, col := range cols {
+		if _, ok := col.settings["softdelete"]; ok {
+			if !isTime(typeIndirect(col.goType)) {
+				return nil, fmt.Errorf("soft delete column `%s` must be a time, got `%s`", col.columnName, col.goType)
+			}
+			return col, nil
+		}
+	}
+	return nil, nil
+}
+
+//find the key filled by a generator on insert
+func findGen(pks []*column) *column {
+	for _, col := range pks {
+		if _, ok := col.settings["gen"]; ok {
+			return col
+		}
 	}
 	return nil
 }
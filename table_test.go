@@ -72,7 +72,8 @@ func (s *tableSuite) TestParseTags(c *C) {
 }
 
 func (s *tableSuite) TestExtractStructColumns_Tags(c *C) {
-	columns, relations := extractStructColumns(reflect.ValueOf(testStructureWithTags{}), nil)
+	columns, relations, err := extractStructColumns(reflect.ValueOf(testStructureWithTags{}), nil)
+	c.Assert(err, IsNil)
 
 	c.Assert(columns, HasLen, 3)                               //check the column count, ignoring 1 column
 	c.Assert(relations, HasLen, 0)                             //check relation count
@@ -85,7 +86,8 @@ func (s *tableSuite) TestExtractStructColumns_Tags(c *C) {
 }
 
 func (s *tableSuite) TestExtractStructColumns_EmbeddedStruct(c *C) {
-	columns, _ := extractStructColumns(reflect.ValueOf(TestProduct{}), nil)
+	columns, _, err := extractStructColumns(reflect.ValueOf(TestProduct{}), nil)
+	c.Assert(err, IsNil)
 
 	c.Assert(columns, HasLen, 5)                               //check the column count
 	c.Assert(columns[0].columnName, Equals, "id")              //column name from the structure
@@ -102,6 +104,88 @@ func (s *tableSuite) TestExtractStructColumns_EmbeddedStruct(c *C) {
 	c.Assert(columns[4].isScanner, Equals, true)
 }
 
+func (s *tableSuite) TestExtractStructColumns_NamedEmbed(c *C) {
+	columns, relations, err := extractStructColumns(reflect.ValueOf(testEmbedStructure{}), nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(relations, HasLen, 0) //embedded value objects are no relations
+	c.Assert(columns, HasLen, 8)
+	c.Assert(columns[2].columnName, Equals, "billing_street")
+	c.Assert(columns[2].goIndex, DeepEquals, []int{2, 0})
+	c.Assert(columns[4].columnName, Equals, "billing_country") //name tag is prefixed
+	c.Assert(columns[4].goIndex, DeepEquals, []int{2, 2})
+	c.Assert(columns[5].columnName, Equals, "shipping_street") //same type embedded twice
+	c.Assert(columns[5].goIndex, DeepEquals, []int{3, 0})
+	c.Assert(columns[7].columnName, Equals, "shipping_country")
+	c.Assert(columns[7].goIndex, DeepEquals, []int{3, 2})
+}
+
+func (s *tableSuite) TestExtractStructColumns_ErrorEmbedNoStruct(c *C) {
+	type testEmbedInvalid struct {
+		Id   int
+		Name string `db:"embed"`
+	}
+
+	_, _, err := extractStructColumns(reflect.ValueOf(testEmbedInvalid{}), nil)
+	c.Assert(err, ErrorMatches, "cannot embed field `Name`, only structs can be embedded")
+}
+
+func (s *tableSuite) TestExtractStructColumns_ErrorInvalidSize(c *C) {
+	type testInvalidSize struct {
+		Id   int
+		Name string `db:"size(large)"`
+	}
+
+	_, _, err := extractStructColumns(reflect.ValueOf(testInvalidSize{}), nil)
+	c.Assert(err, ErrorMatches, "invalid size `large` for column `name`")
+}
+
+func (s *tableSuite) TestExtractStructColumns_KeySize(c *C) {
+	columns, _, err := extractStructColumns(reflect.ValueOf(testNaturalKey{}), nil)
+	c.Assert(err, IsNil)
+	c.Assert(columns, HasLen, 3)
+	c.Assert(columns[0].size, Equals, 255) //keys are bounded
+	c.Assert(columns[1].size, Equals, 255)
+	c.Assert(columns[2].size, Equals, 0)
+}
+
+func (s *tableSuite) TestNewTable_ErrorInvalidVersion(c *C) {
+	type testInvalidVersion struct {
+		Id      int
+		Version string `db:"version"`
+	}
+
+	_, err := newTable(reflect.ValueOf(&testInvalidVersion{}))
+	c.Assert(err, ErrorMatches, "version column `version` must be an integer, got `string`")
+}
+
+func (s *tableSuite) TestNewTable_ErrorInvalidSoftDelete(c *C) {
+	type testInvalidSoftDelete struct {
+		Id      int
+		Deleted bool `db:"softdelete"`
+	}
+
+	_, err := newTable(reflect.ValueOf(&testInvalidSoftDelete{}))
+	c.Assert(err, ErrorMatches, "soft delete column `deleted` must be a time, got `bool`")
+}
+
+func (s *tableSuite) TestExtractStructColumns_JSON(c *C) {
+	columns, relations, err := extractStructColumns(reflect.ValueOf(testJsonStructure{}), nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(relations, HasLen, 0) //json fields are no relations
+	c.Assert(columns, HasLen, 5)
+	c.Assert(columns[0].isJSON, Equals, false)
+	c.Assert(columns[1].columnName, Equals, "settings")
+	c.Assert(columns[1].isJSON, Equals, true)
+	c.Assert(columns[2].columnName, Equals, "settings_ptr")
+	c.Assert(columns[2].isJSON, Equals, true)
+	c.Assert(columns[3].columnName, Equals, "tags")
+	c.Assert(columns[3].isJSON, Equals, true)
+	c.Assert(columns[4].columnName, Equals, "metadata")
+	c.Assert(columns[4].isJSON, Equals, true)
+}
+
 func (s *tableSuite) TestFindPKs(c *C) {
 	//setup test data
 	cai := &column{
@@ -146,17 +230,21 @@ func (s *tableSuite) TestFindPKs(c *C) {
 	c.Assert(findPKs([]*column{cdmmy, cfid, cai}), HasLen, 0)
 
 	//1 match on pk key
-	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cpk, cfid, cid, cai}), HasLen, 1)
-	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cpk, cfid, cid, cai})[0], Equals, cpk)
+	c.Assert(findPKs([]*column{cai, cdmmy, cpk, cfid, cid, cai}), HasLen, 1)
+	c.Assert(findPKs([]*column{cai, cdmmy, cpk, cfid, cid, cai})[0], Equals, cpk)
+
+	//1 match on non integer pk key
+	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cfid, cid}), HasLen, 1)
+	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cfid, cid})[0], Equals, cfpk)
 
 	//2 matches on pk key
-	c.Assert(findPKs([]*column{cai, cfpk, cpk, cdmmy, cpk, cfid, cid, cai}), HasLen, 2)
-	c.Assert(findPKs([]*column{cai, cfpk, cpk, cdmmy, cpk, cfid, cid, cai})[0], Equals, cpk)
-	c.Assert(findPKs([]*column{cai, cfpk, cpk, cdmmy, cpk, cfid, cid, cai})[1], Equals, cpk)
+	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cpk, cfid, cid, cai}), HasLen, 2)
+	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cpk, cfid, cid, cai})[0], Equals, cfpk)
+	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cpk, cfid, cid, cai})[1], Equals, cpk)
 
 	//1 auto match on id name
-	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cfid, cid}), HasLen, 1)
-	c.Assert(findPKs([]*column{cai, cfpk, cdmmy, cfid, cid})[0], Equals, cid)
+	c.Assert(findPKs([]*column{cai, cdmmy, cfid, cid}), HasLen, 1)
+	c.Assert(findPKs([]*column{cai, cdmmy, cfid, cid})[0], Equals, cid)
 }
 
 func (s *tableSuite) TestFindAI(c *C) {
@@ -192,6 +280,85 @@ func (s *tableSuite) TestFindAI(c *C) {
 	c.Assert(findAI([]*column{cdmmy1, cai, cid}, nil), Equals, cai)               //found ai
 	c.Assert(findAI([]*column{cdmmy1, cid, cdmmy1}, []*column{cid}), Equals, cid) //fallback on pk
 	c.Assert(findAI([]*column{cdmmy1, cid, cdmmy1}, []*column{cid, cid}), IsNil)  //no match multiple pks
+
+	cgen := &column{
+		columnName: "id",
+		settings:   map[string]string{"pk": "", "gen": "snowflake"},
+		goType:     reflect.TypeOf(int(1)),
+	}
+	c.Assert(findAI([]*column{cdmmy1, cgen}, []*column{cgen}), IsNil)  //no fallback on generated pk
+	c.Assert(findAI([]*column{cdmmy1, cid}, []*column{cdmmy1}), IsNil) //no fallback on non integer pk
+	c.Assert(findGen([]*column{cid, cgen}), Equals, cgen)              //generated pk
+	c.Assert(findGen([]*column{cid}), IsNil)
+}
+
+func (s *tableSuite) TestPrimaryKey(c *C) {
+	tbl, err := newTable(reflect.ValueOf(testCompositeKey{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.keys, HasLen, 2)
+	c.Assert(tbl.aiColumn, IsNil)
+	c.Assert(tbl.primaryKey(), IsNil) //composite keys cannot be referenced
+	c.Assert(tbl.isKey(tbl.columns[1]), Equals, true)
+	c.Assert(tbl.isKey(tbl.columns[2]), Equals, false)
+
+	tbl, err = newTable(reflect.ValueOf(testNaturalCountry{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.primaryKey(), NotNil)
+	c.Assert(tbl.primaryKey().columnName, Equals, "code")
+}
+
+func (s *tableSuite) TestFindSnapshot(c *C) {
+	tbl, err := newTable(reflect.ValueOf(testTrackedStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.snapshotIndex, DeepEquals, []int{0})
+	c.Assert(tbl.columns, HasLen, 4) //snapshot is no column
+
+	tbl, err = newTable(reflect.ValueOf(testStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.snapshotIndex, IsNil)
+}
+
+func (s *tableSuite) TestFindTimestamp(c *C) {
+	tbl, err := newTable(reflect.ValueOf(testTimestampStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.createdColumn.columnName, Equals, "created_at")
+	c.Assert(tbl.updatedColumn.columnName, Equals, "updated_at")
+
+	tbl, err = newTable(reflect.ValueOf(testTaggedTimestampStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.createdColumn.columnName, Equals, "added")
+	c.Assert(tbl.updatedColumn.columnName, Equals, "changed")
+
+	tbl, err = newTable(reflect.ValueOf(testStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.createdColumn, IsNil)
+	c.Assert(tbl.updatedColumn, IsNil)
+}
+
+func (s *tableSuite) TestFindSoftDelete(c *C) {
+	tbl, err := newTable(reflect.ValueOf(testSoftDeleteStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.deletedColumn, NotNil)
+	c.Assert(tbl.deletedColumn.columnName, Equals, "deleted_at")
+
+	tbl, err = newTable(reflect.ValueOf(testStructure{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.deletedColumn, IsNil)
+}
+
+func (s *tableSuite) TestFindRelation(c *C) {
+	tbl, err := newTable(reflect.ValueOf(testCascadeCustomer{}))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.findRelation("Addresses"), NotNil)
+	c.Assert(tbl.findRelation("addresses"), Equals, tbl.findRelation("Addresses"))
+	c.Assert(tbl.findRelation("Name"), IsNil)
+
+	tbl, err = newTable(reflect.ValueOf(testCascadeAddress{}))
+	c.Assert(err, IsNil)
+	rel := tbl.findRelation("Country")
+	c.Assert(hasSetting(rel.settings, "cascade", "save"), Equals, true)
+	c.Assert(hasSetting(rel.settings, "cascade", "delete"), Equals, false)
+	c.Assert(hasSetting(map[string]string{"cascade": "save|delete"}, "cascade", "delete"), Equals, true)
 }
 
 func (s *tableSuite) TestCamelToSnake(c *C) {
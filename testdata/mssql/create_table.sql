@@ -0,0 +1 @@
+CREATE TABLE [test_all_type_structure] ([id] INT IDENTITY(1,1) PRIMARY KEY,[test_custom_type] INT,[time] DATETIME2,[byte] VARBINARY(MAX),[string] NVARCHAR(MAX),[int] INT,[int64] BIGINT,[float64] FLOAT,[bool] BIT,[null_string] NVARCHAR(MAX),[null_int] BIGINT,[null_float] FLOAT,[null_bool] BIT,[ptr_string] NVARCHAR(MAX),[ptr_int] INT,[ptr_int64] BIGINT,[ptr_float] FLOAT,[ptr_bool] BIT)
@@ -0,0 +1 @@
+CREATE TABLE [test_embed_structure] ([id] INT IDENTITY(1,1) PRIMARY KEY,[name] NVARCHAR(MAX),[billing_street] NVARCHAR(MAX),[billing_city] NVARCHAR(MAX),[billing_country] NVARCHAR(MAX),[shipping_street] NVARCHAR(MAX),[shipping_city] NVARCHAR(MAX),[shipping_country] NVARCHAR(MAX))
@@ -0,0 +1 @@
+CREATE TABLE [test_json_structure] ([id] INT IDENTITY(1,1) PRIMARY KEY,[settings] NVARCHAR(MAX),[settings_ptr] NVARCHAR(MAX),[tags] NVARCHAR(MAX),[metadata] NVARCHAR(MAX))
@@ -0,0 +1 @@
+CREATE TABLE [test_natural_key] ([code] NVARCHAR(255),[hash] VARBINARY(255),[name] NVARCHAR(MAX),PRIMARY KEY ([code],[hash]))
@@ -0,0 +1 @@
+CREATE TABLE [test_uuid_key] ([id] NVARCHAR(36),[name] NVARCHAR(MAX),PRIMARY KEY ([id]))
@@ -0,0 +1 @@
+DELETE FROM [person] WHERE [id] = @p1
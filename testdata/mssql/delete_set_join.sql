@@ -0,0 +1 @@
+DELETE [address] FROM [address] AS [address] JOIN [country] AS [address_country] ON [address].[country_id] = [address_country].[id] WHERE [address_country].[name] = @p1
@@ -0,0 +1 @@
+UPDATE [test_soft_delete_structure] SET [deleted_at] = @p1 WHERE [id] = @p2
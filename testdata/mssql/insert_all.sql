@@ -0,0 +1 @@
+INSERT INTO [person] ([name], [address_id], [optional_address_id]) OUTPUT INSERTED.[id] SELECT [name], [address_id], [optional_address_id] FROM (VALUES (@p1, @p2, @p3, 0), (@p4, @p5, @p6, 1)) AS [storm_rows] ([name], [address_id], [optional_address_id], [storm_ordinal]) ORDER BY [storm_ordinal]
@@ -0,0 +1,3 @@
+SET IDENTITY_INSERT [person] ON
+INSERT INTO [person] ([id], [name], [address_id], [optional_address_id]) VALUES (@p1, @p2, @p3, @p4)
+SET IDENTITY_INSERT [person] OFF
@@ -0,0 +1 @@
+SELECT [person].[id], [person].[name], [person].[address_id], [person].[optional_address_id], [person_address].[id], [person_address].[line1], [person_address].[line2], [person_address].[country_id], [person_address_country].[id], [person_address_country].[name] FROM [person] AS [person] JOIN [address] AS [person_address] ON [person].[address_id] = [person_address].[id] JOIN [country] AS [person_address_country] ON [person_address].[country_id] = [person_address_country].[id] WHERE [person_address].[line1] = @p1
@@ -0,0 +1 @@
+error: dialect does not support `FOR UPDATE` row locking
@@ -0,0 +1 @@
+SELECT [person].[id], [person].[name], [person].[address_id], [person].[optional_address_id] FROM [person] AS [person] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY
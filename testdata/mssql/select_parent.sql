@@ -0,0 +1 @@
+SELECT [address].[id], [address].[line1], [address].[line2], [address].[country_id] FROM [address] AS [address] JOIN [person] AS [address_person_optional_address] ON [address].[id] = [address_person_optional_address].[optional_address_id] WHERE [address_person_optional_address].[name] = @p1 GROUP BY [address].[id]
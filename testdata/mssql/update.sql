@@ -0,0 +1 @@
+UPDATE [person] SET [name] = @p1, [address_id] = @p2, [optional_address_id] = @p3 WHERE [id] = @p4
@@ -0,0 +1 @@
+UPDATE [person] SET [name] = @p1 WHERE [id] = @p2
@@ -0,0 +1 @@
+UPDATE [test_composite_key] SET [position] = @p1 WHERE [customer_id] = @p2 AND [tag_id] = @p3
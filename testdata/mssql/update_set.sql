@@ -0,0 +1 @@
+UPDATE [address] SET [line2] = @p1, [country_id] = @p2 WHERE [address].[line1] = @p3
@@ -0,0 +1 @@
+UPDATE [test_versioned_structure] SET [name] = @p1, [version] = [version] + 1 WHERE [id] = @p2 AND [version] = @p3
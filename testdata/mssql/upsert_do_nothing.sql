@@ -0,0 +1 @@
+unsupported
@@ -0,0 +1 @@
+SELECT COUNT(DISTINCT `person`.`id`) FROM `person` AS `person` JOIN `telephone` AS `person_telephones` ON `person`.`id` = `person_telephones`.`person_id` WHERE `person_telephones`.`number` = ?
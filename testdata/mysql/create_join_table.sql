@@ -0,0 +1 @@
+CREATE TABLE `test_customer_tag` (`test_tag_id` INT,`test_tagged_customer_id` INT,PRIMARY KEY (`test_tag_id`,`test_tagged_customer_id`))
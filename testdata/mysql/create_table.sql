@@ -0,0 +1 @@
+CREATE TABLE `test_all_type_structure` (`id` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,`test_custom_type` INT,`time` DATETIME,`byte` LONGBLOB,`string` LONGTEXT,`int` INT,`int64` BIGINT,`float64` DOUBLE,`bool` BOOLEAN,`null_string` LONGTEXT,`null_int` BIGINT,`null_float` DOUBLE,`null_bool` BOOLEAN,`ptr_string` LONGTEXT,`ptr_int` INT,`ptr_int64` BIGINT,`ptr_float` DOUBLE,`ptr_bool` BOOLEAN)
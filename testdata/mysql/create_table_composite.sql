@@ -0,0 +1 @@
+CREATE TABLE `test_composite_key` (`customer_id` INT,`tag_id` INT,`position` INT,PRIMARY KEY (`customer_id`,`tag_id`))
@@ -0,0 +1 @@
+CREATE TABLE `test_embed_structure` (`id` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,`name` LONGTEXT,`billing_street` LONGTEXT,`billing_city` LONGTEXT,`billing_country` LONGTEXT,`shipping_street` LONGTEXT,`shipping_city` LONGTEXT,`shipping_country` LONGTEXT)
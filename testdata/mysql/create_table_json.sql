@@ -0,0 +1 @@
+CREATE TABLE `test_json_structure` (`id` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,`settings` JSON,`settings_ptr` JSON,`tags` JSON,`metadata` JSON)
@@ -0,0 +1 @@
+CREATE TABLE `test_natural_key` (`code` VARCHAR(255),`hash` VARBINARY(255),`name` LONGTEXT,PRIMARY KEY (`code`,`hash`))
@@ -0,0 +1 @@
+CREATE TABLE `test_uuid_key` (`id` VARCHAR(36),`name` LONGTEXT,PRIMARY KEY (`id`))
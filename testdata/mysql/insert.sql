@@ -0,0 +1 @@
+INSERT INTO `person` (`name`, `address_id`, `optional_address_id`) VALUES (?, ?, ?)
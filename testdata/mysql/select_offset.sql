@@ -0,0 +1 @@
+SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` LIMIT 18446744073709551615 OFFSET 20
@@ -0,0 +1 @@
+SELECT `person`.`id`, `person`.`name`, `person`.`address_id`, `person`.`optional_address_id` FROM `person` AS `person` ORDER BY `person`.`name` ASC LIMIT 10 OFFSET 20
@@ -0,0 +1 @@
+SELECT `test_soft_delete_structure`.`id`, `test_soft_delete_structure`.`name`, `test_soft_delete_structure`.`deleted_at` FROM `test_soft_delete_structure` AS `test_soft_delete_structure` JOIN `test_soft_delete_note` AS `test_soft_delete_structure_notes` ON `test_soft_delete_structure`.`id` = `test_soft_delete_structure_notes`.`test_soft_delete_structure_id` AND `test_soft_delete_structure_notes`.`deleted_at` IS NULL WHERE `test_soft_delete_structure`.`deleted_at` IS NULL AND (`test_soft_delete_structure`.`name` = ? OR `test_soft_delete_structure_notes`.`text` = ?) GROUP BY `test_soft_delete_structure`.`id`
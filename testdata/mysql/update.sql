@@ -0,0 +1 @@
+UPDATE `person` SET `name` = ?, `address_id` = ?, `optional_address_id` = ? WHERE `id` = ?
@@ -0,0 +1 @@
+INSERT INTO `test_upsert_structure` (`email`, `name`, `score`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `score` = VALUES(`score`)
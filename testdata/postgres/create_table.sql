@@ -0,0 +1 @@
+CREATE TABLE "test_all_type_structure" ("id" INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,"test_custom_type" INTEGER,"time" TIMESTAMP WITH TIME ZONE,"byte" BYTEA,"string" TEXT,"int" INTEGER,"int64" BIGINT,"float64" DOUBLE PRECISION,"bool" BOOLEAN,"null_string" TEXT,"null_int" BIGINT,"null_float" DOUBLE PRECISION,"null_bool" BOOLEAN,"ptr_string" TEXT,"ptr_int" INTEGER,"ptr_int64" BIGINT,"ptr_float" DOUBLE PRECISION,"ptr_bool" BOOLEAN)
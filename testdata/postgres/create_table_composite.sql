@@ -0,0 +1 @@
+CREATE TABLE "test_composite_key" ("customer_id" INTEGER,"tag_id" INTEGER,"position" INTEGER,PRIMARY KEY ("customer_id","tag_id"))
@@ -0,0 +1 @@
+CREATE TABLE "test_json_structure" ("id" INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,"settings" JSONB,"settings_ptr" JSONB,"tags" JSONB,"metadata" JSONB)
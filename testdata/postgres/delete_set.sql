@@ -0,0 +1 @@
+DELETE FROM "address" WHERE "address"."line1" = $1
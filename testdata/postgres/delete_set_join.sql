@@ -0,0 +1 @@
+DELETE FROM "address" WHERE "id" IN (SELECT "matched"."id" FROM (SELECT "address"."id" FROM "address" AS "address" JOIN "country" AS "address_country" ON "address"."country_id" = "address_country"."id" WHERE "address_country"."name" = $1) AS "matched")
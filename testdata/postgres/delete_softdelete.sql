@@ -0,0 +1 @@
+UPDATE "test_soft_delete_structure" SET "deleted_at" = $1 WHERE "id" = $2
@@ -0,0 +1 @@
+INSERT INTO "person" ("name", "address_id", "optional_address_id") VALUES ($1, $2, $3), ($4, $5, $6) RETURNING "id"
@@ -0,0 +1,2 @@
+INSERT INTO "person" ("id", "name", "address_id", "optional_address_id") VALUES ($1, $2, $3, $4)
+SELECT setval(pg_get_serial_sequence('"person"', 'id'), MAX("id")) FROM "person"
@@ -0,0 +1 @@
+SELECT "person"."id", "person"."name", "person"."address_id", "person"."optional_address_id" FROM "person" AS "person" JOIN "address" AS "person_optional_address" ON "person"."optional_address_id" = "person_optional_address"."id" JOIN "country" AS "person_optional_address_country" ON "person_optional_address"."country_id" = "person_optional_address_country"."id" WHERE "person"."name" = $1 AND "person"."id" IN ($2, $3) AND "person_optional_address_country"."name" = 'what?' ORDER BY "person"."id" DESC
@@ -0,0 +1 @@
+SELECT "test_all_type_structure"."id", "test_all_type_structure"."test_custom_type", "test_all_type_structure"."time", "test_all_type_structure"."byte", "test_all_type_structure"."string", "test_all_type_structure"."int", "test_all_type_structure"."int64", "test_all_type_structure"."float64", "test_all_type_structure"."bool", "test_all_type_structure"."null_string", "test_all_type_structure"."null_int", "test_all_type_structure"."null_float", "test_all_type_structure"."null_bool", "test_all_type_structure"."ptr_string", "test_all_type_structure"."ptr_int", "test_all_type_structure"."ptr_int64", "test_all_type_structure"."ptr_float", "test_all_type_structure"."ptr_bool" FROM "test_all_type_structure" AS "test_all_type_structure" WHERE "test_all_type_structure"."bool" = TRUE OR ("test_all_type_structure"."bool" = FALSE AND "test_all_type_structure"."string" = 'true')
@@ -0,0 +1 @@
+WITH RECURSIVE "storm_tree" ("id", "name", "parent_id", "storm_depth") AS (SELECT "test_category"."id", "test_category"."name", "test_category"."parent_id", 1 FROM "test_category" AS "test_category" WHERE "test_category"."parent_id" = $1 AND "test_category"."id" <> $2 UNION ALL SELECT "test_category"."id", "test_category"."name", "test_category"."parent_id", "storm_tree"."storm_depth" + 1 FROM "test_category" AS "test_category" JOIN "storm_tree" ON "test_category"."parent_id" = "storm_tree"."id" WHERE "test_category"."id" <> $3 AND "storm_tree"."storm_depth" < $4) SELECT "storm_tree"."id", "storm_tree"."name", "storm_tree"."parent_id", "storm_tree"."storm_depth" FROM "storm_tree" ORDER BY "storm_tree"."storm_depth", "storm_tree"."id"
@@ -0,0 +1 @@
+SELECT "test_transfer"."id", "test_transfer"."amount", "test_transfer"."source_code", "test_transfer"."target_code" FROM "test_transfer" AS "test_transfer" JOIN "test_account" AS "test_transfer_target" ON "test_transfer"."target_code" = "test_transfer_target"."code" WHERE "test_transfer_target"."name" = $1
@@ -0,0 +1 @@
+UPDATE "person" SET "name" = $1, "address_id" = $2, "optional_address_id" = $3 WHERE "id" = $4
@@ -0,0 +1 @@
+UPDATE "person" SET "name" = $1 WHERE "id" = $2
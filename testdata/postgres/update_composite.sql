@@ -0,0 +1 @@
+UPDATE "test_composite_key" SET "position" = $1 WHERE "customer_id" = $2 AND "tag_id" = $3
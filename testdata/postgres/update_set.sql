@@ -0,0 +1 @@
+UPDATE "address" SET "line2" = $1, "country_id" = $2 WHERE "address"."line1" = $3
@@ -0,0 +1 @@
+UPDATE "address" SET "country_id" = $1 WHERE "id" IN (SELECT "matched"."id" FROM (SELECT "address"."id" FROM "address" AS "address" JOIN "person" AS "address_person_address" ON "address"."id" = "address_person_address"."address_id" WHERE "address_person_address"."name" = $2) AS "matched")
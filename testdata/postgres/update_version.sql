@@ -0,0 +1 @@
+UPDATE "test_versioned_structure" SET "name" = $1, "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3
@@ -0,0 +1 @@
+INSERT INTO "test_upsert_structure" ("email", "name", "score") VALUES ($1, $2, $3) ON CONFLICT ("email") DO UPDATE SET "name" = excluded."name", "score" = excluded."score"
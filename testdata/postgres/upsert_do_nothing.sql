@@ -0,0 +1 @@
+INSERT INTO "test_upsert_structure" ("id", "email", "name", "score") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO NOTHING
@@ -0,0 +1 @@
+CREATE TABLE `test_customer_tag` (`test_tag_id` INTEGER,`test_tagged_customer_id` INTEGER,PRIMARY KEY (`test_tag_id`,`test_tagged_customer_id`))
@@ -0,0 +1 @@
+CREATE TABLE `test_all_type_structure` (`id` INTEGER PRIMARY KEY,`test_custom_type` INTEGER,`time` DATETIME,`byte` BLOB,`string` TEXT,`int` INTEGER,`int64` BIGINT,`float64` REAL,`bool` BOOL,`null_string` TEXT,`null_int` BIGINT,`null_float` REAL,`null_bool` BOOL,`ptr_string` TEXT,`ptr_int` INTEGER,`ptr_int64` BIGINT,`ptr_float` REAL,`ptr_bool` BOOL)
@@ -0,0 +1 @@
+CREATE TABLE `test_embed_structure` (`id` INTEGER PRIMARY KEY,`name` TEXT,`billing_street` TEXT,`billing_city` TEXT,`billing_country` TEXT,`shipping_street` TEXT,`shipping_city` TEXT,`shipping_country` TEXT)
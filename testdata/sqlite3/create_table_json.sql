@@ -0,0 +1 @@
+CREATE TABLE `test_json_structure` (`id` INTEGER PRIMARY KEY,`settings` TEXT,`settings_ptr` TEXT,`tags` TEXT,`metadata` TEXT)
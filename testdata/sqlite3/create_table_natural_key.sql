@@ -0,0 +1 @@
+CREATE TABLE `test_natural_key` (`code` VARCHAR(255),`hash` BLOB,`name` TEXT,PRIMARY KEY (`code`,`hash`))
@@ -0,0 +1 @@
+SELECT `test_tagged_customer`.`id`, `test_tagged_customer`.`name` FROM `test_tagged_customer` AS `test_tagged_customer` JOIN `test_customer_tag` AS `test_tagged_customer_tags_link` ON `test_tagged_customer`.`id` = `test_tagged_customer_tags_link`.`test_tagged_customer_id` JOIN `test_tag` AS `test_tagged_customer_tags` ON `test_tagged_customer_tags_link`.`test_tag_id` = `test_tagged_customer_tags`.`id` WHERE `test_tagged_customer_tags`.`name` = ? GROUP BY `test_tagged_customer`.`id`
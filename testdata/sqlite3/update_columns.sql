@@ -0,0 +1 @@
+UPDATE `person` SET `name` = ? WHERE `id` = ?
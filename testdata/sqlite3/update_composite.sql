@@ -0,0 +1 @@
+UPDATE `test_composite_key` SET `position` = ? WHERE `customer_id` = ? AND `tag_id` = ?
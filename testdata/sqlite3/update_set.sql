@@ -0,0 +1 @@
+UPDATE `address` SET `line2` = ?, `country_id` = ? WHERE `address`.`line1` = ?
@@ -0,0 +1 @@
+UPDATE `test_versioned_structure` SET `name` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?
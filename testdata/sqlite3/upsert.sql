@@ -0,0 +1 @@
+INSERT INTO `test_upsert_structure` (`email`, `name`, `score`) VALUES (?, ?, ?) ON CONFLICT (`email`) DO UPDATE SET `name` = excluded.`name`, `score` = excluded.`score`
@@ -0,0 +1 @@
+INSERT INTO `test_upsert_structure` (`id`, `email`, `name`, `score`) VALUES (?, ?, ?, ?) ON CONFLICT (`id`) DO NOTHING
@@ -79,14 +79,57 @@ func (transaction *Transaction) Dependent(i interface{}, columns ...string) erro
 	return transaction.Query().Dependent(i, columns...)
 }
 
+//DependentRecursive loads the subtrees of the self referencing relations created with Recursive
+func (transaction *Transaction) DependentRecursive(i interface{}, recursions ...Recursion) error {
+	return transaction.Query().DependentRecursive(i, recursions...)
+}
+
 //Delete will delete the provided structure from the datastore
+//Structures with a soft delete column are marked as deleted instead
 func (transaction *Transaction) Delete(i interface{}) error {
-	return transaction.storm.deleteEntity(i, transaction)
+	return transaction.storm.deleteEntity(i, transaction, false)
+}
+
+//HardDelete will delete the provided structure from the datastore, also when it has a soft delete column
+func (transaction *Transaction) HardDelete(i interface{}) error {
+	return transaction.storm.deleteEntity(i, transaction, true)
 }
 
 //Save will insert or update the provided structure in the datastore
-func (transaction *Transaction) Save(i interface{}) error {
-	return transaction.storm.saveEntity(i, transaction)
+func (transaction *Transaction) Save(i interface{}, options ...SaveOption) error {
+	return transaction.storm.saveEntity(i, transaction, options...)
+}
+
+//Insert will insert the provided structure in the datastore, also when the primary key is already set
+func (transaction *Transaction) Insert(i interface{}) error {
+	return transaction.storm.insert(i, transaction)
+}
+
+//InsertAll will insert all the structures of the provided slice with multi-row inserts
+func (transaction *Transaction) InsertAll(i interface{}) error {
+	return transaction.storm.insertAll(i, transaction)
+}
+
+//Update will update the provided structure in the datastore
+//RecordNotFound is returned when no record is affected
+func (transaction *Transaction) Update(i interface{}) error {
+	return transaction.storm.update(i, transaction)
+}
+
+//UpdateColumns will only update the provided columns (column or field names) of the structure in the datastore
+//RecordNotFound is returned when no record is affected
+func (transaction *Transaction) UpdateColumns(i interface{}, columns ...string) error {
+	return transaction.storm.update(i, transaction, Columns(columns...))
+}
+
+//Upsert will insert the provided structure or update the existing record when it conflicts on the unique columns
+func (transaction *Transaction) Upsert(i interface{}, conflict *Conflict) error {
+	return transaction.storm.upsert(i, conflict, transaction)
+}
+
+//Changes returns the old and new values of the changed columns of a tracked structure (embedding storm.Snapshot)
+func (transaction *Transaction) Changes(i interface{}) (map[string]Change, error) {
+	return transaction.storm.Changes(i)
 }
 
 //Commit will commit the current transaction and closes
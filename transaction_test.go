@@ -146,6 +146,49 @@ func (s *transactionSuite) TestSave_Update(c *C) {
 	c.Assert(compare.Name, Equals, first.Name)
 }
 
+func (s *transactionSuite) TestInsert(c *C) {
+	insert := &Person{Id: 100, Name: "imported"}
+	var compare *Person
+
+	c.Assert(s.tx.Insert(&insert), IsNil)
+	c.Assert(s.tx.Find(&compare, 100), IsNil)
+	c.Assert(compare.Name, Equals, insert.Name)
+
+	//should not be found
+	c.Assert(s.db.Find(&compare, 100), Equals, sql.ErrNoRows)
+}
+
+func (s *transactionSuite) TestUpdate(c *C) {
+	first := &Person{Name: "first"}
+	c.Assert(s.db.Save(&first), IsNil)
+
+	updated := &Person{Id: first.Id, Name: `test updated`}
+	c.Assert(s.tx.Update(&updated), IsNil)
+	c.Assert(s.tx.Update(&Person{Id: 1000}), Equals, RecordNotFound)
+
+	var compare *Person
+
+	//current transaction new value
+	c.Assert(s.tx.Find(&compare, first.Id), IsNil)
+	c.Assert(compare.Name, Equals, updated.Name)
+
+	//should be old value
+	c.Assert(s.db.Find(&compare, first.Id), IsNil)
+	c.Assert(compare.Name, Equals, first.Name)
+}
+
+func (s *transactionSuite) TestUpdateColumns(c *C) {
+	first := &Person{Name: "first", AddressId: 1}
+	c.Assert(s.db.Save(&first), IsNil)
+
+	c.Assert(s.tx.UpdateColumns(&Person{Id: first.Id, Name: "ignored", AddressId: 2}, "AddressId"), IsNil)
+
+	var compare *Person
+	c.Assert(s.tx.Find(&compare, first.Id), IsNil)
+	c.Assert(compare.Name, Equals, "first")
+	c.Assert(compare.AddressId, Equals, 2)
+}
+
 //simple test for the passtrough transaction
 func (s *transactionSuite) TestQuery(c *C) {
 	var compare *Person
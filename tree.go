@@ -0,0 +1,288 @@
+package storm
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//Recursive loads a self referencing relation up to the provided depth when passed to DependentRecursive, a depth of 0 loads the whole subtree
+//The subtree is loaded with a recursive common table expression when the dialect supports it, otherwise one query per level
+//Example:
+// type Category struct {
+//	Id       int
+//	ParentId sql.NullInt64
+//	Children []Category `db:"fk(parent_id)"`
+// }
+//
+// err := db.DependentRecursive(&root, storm.Recursive("Children", 3))
+func Recursive(relation string, maxDepth int) Recursion {
+	return Recursion{relation: relation, maxDepth: maxDepth}
+}
+
+//Recursion is a self referencing relation loaded with its subtree by DependentRecursive, created with Recursive
+type Recursion struct {
+	relation string
+	maxDepth int
+}
+
+//treeNode is a loaded record of the subtree with the record it belongs to
+type treeNode struct {
+	v      reflect.Value
+	parent reflect.Value
+}
+
+//fetchRecursive loads the subtree of the self referencing relation into the structure
+func (query *Query) fetchRecursive(v reflect.Value, tbl *table, name string, maxDepth int) error {
+	rel := tbl.findRelation(name)
+	if rel == nil {
+		return fmt.Errorf("unknown relation `%s` in table `%s`", name, tbl.tableName)
+	}
+	if rel.relTable != tbl || rel.joinTable != "" || rel.goType.Kind() != reflect.Slice {
+		return fmt.Errorf("relation `%s` of table `%s` is not a self referencing one to many relation", rel.name, tbl.tableName)
+	}
+
+	key := rel.referencedKey(tbl)
+	if key == nil {
+		return fmt.Errorf("cannot reference table `%s` without a single primary key", tbl.tableName)
+	}
+
+	var (
+		levels [][]reflect.Value
+		err    error
+	)
+	//only a unique key guarantees the subtree cannot cycle without passing the root record
+	if query.ctx.Dialect().Capabilities().Recursive && len(tbl.keys) == 1 && tbl.keys[0] == key {
+		levels, err = query.fetchTree(v, tbl, rel, key, maxDepth)
+	} else {
+		levels, err = query.fetchTreeLevels(v, tbl, rel, key, maxDepth)
+	}
+	if err != nil {
+		return err
+	}
+
+	//find the record each loaded record belongs to, only records of the previous level are candidates
+	var (
+		nodes   [][]treeNode
+		parents = map[interface{}]reflect.Value{treeKey(v.FieldByIndex(key.goIndex)): v}
+	)
+	for _, level := range levels {
+		var linked []treeNode
+		next := make(map[interface{}]reflect.Value)
+		for _, node := range level {
+			parent, ok := parents[treeKey(node.FieldByIndex(rel.relColumn.goIndex))]
+			if !ok {
+				continue
+			}
+			linked = append(linked, treeNode{v: node, parent: parent})
+			next[treeKey(node.FieldByIndex(key.goIndex))] = node
+		}
+		nodes = append(nodes, linked)
+		parents = next
+	}
+
+	//assign bottom up, the children are complete before they are copied into a slice of values
+	v.FieldByIndex(rel.goIndex).Set(reflect.Zero(rel.goType))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		for _, node := range nodes[i] {
+			field := node.parent.FieldByIndex(rel.goIndex)
+			if rel.goType.Elem().Kind() == reflect.Ptr {
+				field.Set(reflect.Append(field, node.v.Addr()))
+			} else {
+				field.Set(reflect.Append(field, node.v))
+			}
+		}
+	}
+	return nil
+}
+
+//fetchTree loads the subtree with a single recursive query, the records are grouped by their level
+//Records already loaded are skipped to stop on cycles, like the query per level does
+func (query *Query) fetchTree(v reflect.Value, tbl *table, rel *relation, key *column, maxDepth int) ([][]reflect.Value, error) {
+	sqlQuery, bind := query.generateRecursiveSQL(tbl, rel, key, v.FieldByIndex(key.goIndex).Interface(), maxDepth)
+	if query.ctx.logger() != nil {
+		query.ctx.logger().Printf("`%s` binding : %v", sqlQuery, bind)
+	}
+
+	rows, err := query.ctx.DB().Query(sqlQuery, bind...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		levels  [][]reflect.Value
+		visited = map[interface{}]bool{treeKey(v.FieldByIndex(key.goIndex)): true}
+	)
+	for rows.Next() {
+		var (
+			node  = reflect.New(tbl.goType)
+			depth int
+			dest  = make([]interface{}, 0, len(tbl.columns)+1)
+		)
+		for _, col := range tbl.columns {
+			dest = append(dest, col.scanDest(node.Elem()))
+		}
+		if err = rows.Scan(append(dest, &depth)...); err != nil {
+			return nil, err
+		}
+
+		k := treeKey(node.Elem().FieldByIndex(key.goIndex))
+		if visited[k] {
+			continue
+		}
+		visited[k] = true
+
+		if err = tbl.takeSnapshot(node.Elem()); err != nil {
+			return nil, err
+		}
+		if err = tbl.callbacks.invoke(node, "OnInit", query.ctx); err != nil {
+			return nil, err
+		}
+
+		for len(levels) < depth {
+			levels = append(levels, nil)
+		}
+		levels[depth-1] = append(levels[depth-1], node.Elem())
+	}
+	return levels, rows.Err()
+}
+
+//fetchTreeLevels loads the subtree with a query per level, records already loaded are skipped to stop on cycles
+func (query *Query) fetchTreeLevels(v reflect.Value, tbl *table, rel *relation, key *column, maxDepth int) ([][]reflect.Value, error) {
+	var (
+		levels  [][]reflect.Value
+		keys    = []interface{}{v.FieldByIndex(key.goIndex).Interface()}
+		visited = map[interface{}]bool{treeKey(v.FieldByIndex(key.goIndex)): true}
+	)
+
+	chunkSize := query.ctx.Dialect().Capabilities().MaxBindVars
+	for depth := 1; len(keys) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		var level []reflect.Value
+		for start := 0; start < len(keys); {
+			end := len(keys)
+			if chunkSize > 0 && end-start > chunkSize {
+				end = start + chunkSize
+			}
+
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", end-start), ", ")
+			found := reflect.New(reflect.SliceOf(reflect.PtrTo(tbl.goType)))
+			err := query.relatedQuery().
+				Where(fmt.Sprintf("%s IN (%s)", rel.relColumn.columnName, placeholders), keys[start:end]...).
+				Order(key.columnName, ASC).
+				Find(found.Interface())
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+
+			for i := 0; i < found.Elem().Len(); i++ {
+				level = append(level, found.Elem().Index(i).Elem())
+			}
+			start = end
+		}
+
+		keys = nil
+		var unvisited []reflect.Value
+		for _, node := range level {
+			k := treeKey(node.FieldByIndex(key.goIndex))
+			if visited[k] {
+				continue
+			}
+			visited[k] = true
+			unvisited = append(unvisited, node)
+			keys = append(keys, node.FieldByIndex(key.goIndex).Interface())
+		}
+		levels = append(levels, unvisited)
+	}
+	return levels, nil
+}
+
+//generateRecursiveSQL creates the recursive query selecting the subtree with the level of each record
+//Every record has a single parent, so a cycle in the subtree always returns to the root record, which is never selected
+func (query *Query) generateRecursiveSQL(tbl *table, rel *relation, key *column, keyValue interface{}, maxDepth int) (string, []interface{}) {
+	var (
+		d         = query.ctx.Dialect()
+		tblName   = d.Quote(tbl.tableName)
+		tree      = d.Quote("storm_tree")
+		depth     = d.Quote("storm_depth")
+		fkColumn  = d.Quote(rel.relColumn.columnName)
+		keyColumn = d.Quote(key.columnName)
+		names     []string
+		bind      = []interface{}{keyValue, keyValue, keyValue}
+	)
+
+	columns := func(alias string) string {
+		sql := bytes.NewBufferString("")
+		for i, col := range tbl.columns {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(alias + "." + d.Quote(col.columnName))
+		}
+		return sql.String()
+	}
+
+	for _, col := range tbl.columns {
+		names = append(names, col.columnName)
+	}
+	names = append(names, "storm_depth")
+
+	//the direct children are the first level
+	sql := bytes.NewBufferString(fmt.Sprintf("SELECT %s, 1 FROM %s AS %s WHERE %s.%s = ? AND %s.%s <> ?", columns(tblName), tblName, tblName, tblName, fkColumn, tblName, keyColumn))
+	if condition := query.softDeleteCondition(tbl, tbl.tableName); condition != "" {
+		sql.WriteString(" AND " + condition)
+	}
+
+	//the children of the previous level
+	sql.WriteString(fmt.Sprintf(" UNION ALL SELECT %s, %s.%s + 1 FROM %s AS %s JOIN %s ON %s.%s = %s.%s",
+		columns(tblName), tree, depth, tblName, tblName, tree, tblName, fkColumn, tree, keyColumn))
+
+	conditions := []string{fmt.Sprintf("%s.%s <> ?", tblName, keyColumn)}
+	if maxDepth > 0 {
+		conditions = append(conditions, fmt.Sprintf("%s.%s < ?", tree, depth))
+		bind = append(bind, maxDepth)
+	}
+	if condition := query.softDeleteCondition(tbl, tbl.tableName); condition != "" {
+		conditions = append(conditions, condition)
+	}
+	sql.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+
+	selectSQL := fmt.Sprintf("SELECT %s, %s.%s FROM %s ORDER BY %s.%s, %s.%s", columns(tree), tree, depth, tree, tree, depth, tree, keyColumn)
+	sqlQuery := d.RecursiveSQL("storm_tree", names, sql.String(), selectSQL)
+	return rebind(d, sqlQuery), bind
+}
+
+//treeKey normalizes a key value to match foreign keys of another type, e.g. a sql.NullInt64 referencing an int
+func treeKey(field reflect.Value) interface{} {
+	value := field.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		value, _ = valuer.Value()
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes())
+		}
+	case reflect.Invalid:
+		return nil
+	}
+	return rv.Interface()
+}